@@ -1,20 +1,39 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"url-shortener/internal/analytics"
 	"url-shortener/internal/auth"
+	"url-shortener/internal/blocklist"
 	"url-shortener/internal/click"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/export"
+	"url-shortener/internal/geoip"
+	"url-shortener/internal/importer"
+	"url-shortener/internal/notification"
+	"url-shortener/internal/payload"
+	"url-shortener/internal/qr"
+	"url-shortener/internal/reaper"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/transfer"
 	"url-shortener/internal/url"
 	"url-shortener/internal/user"
+	"url-shortener/internal/useragent"
+	"url-shortener/internal/workspace"
 )
 
 func main() {
@@ -55,6 +74,7 @@ func main() {
 
 	// Gin setup
 	r := gin.Default()
+	configureClientIP(r)
 
 	// CORS
 	r.Use(cors.New(cors.Config{
@@ -64,27 +84,110 @@ func main() {
 		AllowCredentials: true,
 	}))
 
-	// Cloudinary
-	cld, err := cloudinary.NewFromParams(
-		mustGetEnv("CLOUDINARY_CLOUD_NAME"),
-		mustGetEnv("CLOUDINARY_API_KEY"),
-		mustGetEnv("CLOUDINARY_API_SECRET"),
-	)
-	if err != nil {
-		log.Fatal("❌ Cloudinary init error:", err)
-	}
+	// Object storage for QR images
+	store := newStorage(r, port)
 
 	// Repositories & Services
 	userRepo := user.NewRepository(db)
 	authService := auth.NewService(userRepo)
 	authHandler := auth.NewHandler(authService)
 
+	domainRepo := domain.NewRepository(db)
+	domainVerifier := domain.NewVerifier(net.DefaultResolver, domain.NewHTTPClient(10*time.Second))
+	domainService := domain.NewService(domainRepo, domainVerifier)
+	domainHandler := domain.NewHandler(domainService)
+
+	workspaceRepo := workspace.NewRepository(db)
+	workspaceService := workspace.NewService(workspaceRepo, userRepo)
+	workspaceHandler := workspace.NewHandler(workspaceService)
+
 	urlRepo := url.NewRepository(db)
 	clickRepo := click.NewRepository(db)
-	clickService := click.NewService(clickRepo)
-	urlService := url.NewService(urlRepo, clickService, cld)
+	geoDB := geoIPDatabase()
+	clickService := click.NewService(clickRepo, clickSalt(), userAgentParser(), geoDB)
+	blocklistRepo := blocklist.NewRepository(db)
+	var extraReserved []string
+	if raw := os.Getenv("RESERVED_CODES"); raw != "" {
+		extraReserved = strings.Split(raw, ",")
+	}
+	blocklistService, err := blocklist.NewService(blocklistRepo, extraReserved)
+	if err != nil {
+		log.Fatal("❌ Blocklist init error:", err)
+	}
+	blocklistHandler := blocklist.NewHandler(blocklistService)
+
+	qrCache := qr.NewCache(getEnvInt("QR_CACHE_MB", 64) << 20)
+	qrRenderer := qr.NewRenderer(qr.NewHTTPLogoFetcher(10*time.Second), qrCache)
+	qrWorkerConfig := url.DefaultQRWorkerConfig
+	qrWorkerConfig.MaxAttempts = getEnvInt("QR_UPLOAD_MAX_ATTEMPTS", qrWorkerConfig.MaxAttempts)
+	qrWorkerConfig.PollInterval = getEnvDuration("QR_UPLOAD_POLL_INTERVAL", qrWorkerConfig.PollInterval)
+	qrWorker := url.NewQRWorker(urlRepo, store, qrRenderer, qrWorkerConfig)
+	urlService := url.NewService(urlRepo, clickService, domainService, workspaceService, store, blocklistService, qrRenderer, qrWorker)
 	urlHandler := url.NewHandler(urlService)
 
+	var mailSender notification.Sender
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		mailSender = notification.NewSMTPSender(notification.SMTPConfig{
+			Host:     host,
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     mustGetEnv("SMTP_FROM"),
+		})
+	} else {
+		mailSender = notification.NewLogSender()
+	}
+	notificationRepo := notification.NewRepository(db)
+	notificationService := notification.NewService(notificationRepo, userRepo, mailSender)
+	notificationHandler := notification.NewHandler(notificationService)
+
+	transferRepo := transfer.NewRepository(db)
+	transferService := transfer.NewService(transferRepo, urlRepo, userRepo, workspaceService, notificationService)
+	transferHandler := transfer.NewHandler(transferService)
+
+	importRepo := importer.NewRepository(db)
+	importService := importer.NewService(importRepo, urlService, workspaceService)
+	importHandler := importer.NewHandler(importService)
+
+	exportRepo := export.NewRepository(db)
+	exportService := export.NewService(exportRepo, workspaceService)
+	exportHandler := export.NewHandler(exportService)
+
+	payloadRepo := payload.NewRepository(db)
+	payloadService := payload.NewService(payloadRepo, urlService, workspaceService, qrRenderer)
+	payloadHandler := payload.NewHandler(payloadService)
+
+	analyticsRepo := analytics.NewRepository(db)
+	analyticsService := analytics.NewService(analyticsRepo, urlService, workspaceService)
+	analyticsHandler := analytics.NewHandler(analyticsService)
+
+	// Background jobs
+	expiryJob := notification.NewExpiryJob(
+		urlRepo,
+		notificationService,
+		getEnvDuration("EXPIRY_NOTIFY_WINDOW", 72*time.Hour),
+		getEnvDuration("EXPIRY_NOTIFY_INTERVAL", time.Hour),
+	)
+	go expiryJob.Run(context.Background())
+	go qrWorker.Run(context.Background())
+	go importService.Run(context.Background())
+	if geoDB != nil {
+		go geoDB.Run(context.Background(), getEnvDuration("GEOIP_RELOAD_INTERVAL", time.Minute))
+	}
+
+	// The reaper deletes data, so it only runs when a mode is chosen.
+	if reaperMode := os.Getenv("REAPER_MODE"); reaperMode != "" {
+		linkReaper, err := reaper.New(urlRepo, store, reaper.Config{
+			GracePeriod: getEnvDuration("REAPER_GRACE_PERIOD", 30*24*time.Hour),
+			Mode:        reaperMode,
+			Interval:    getEnvDuration("REAPER_INTERVAL", 6*time.Hour),
+		})
+		if err != nil {
+			log.Fatal("❌ Reaper config error:", err)
+		}
+		go linkReaper.Run(context.Background())
+	}
+
 	// Routes
 	api := r.Group("/api")
 	{
@@ -110,9 +213,245 @@ func main() {
 			auth.Middleware(auth.JWTService),
 			urlHandler.DeleteURL,
 		)
+
+		api.POST("/urls/:id/disable",
+			auth.Middleware(auth.JWTService),
+			urlHandler.DisableURL,
+		)
+
+		api.POST("/urls/:id/enable",
+			auth.Middleware(auth.JWTService),
+			urlHandler.EnableURL,
+		)
+
+		api.PUT("/urls/:id/expired-behavior",
+			auth.Middleware(auth.JWTService),
+			urlHandler.UpdateExpiredBehavior,
+		)
+
+		api.PUT("/urls/:id/expiry",
+			auth.Middleware(auth.JWTService),
+			urlHandler.RenewExpiry,
+		)
+
+		api.PUT("/urls/:id/redirect",
+			auth.Middleware(auth.JWTService),
+			urlHandler.UpdateRedirectStatus,
+		)
+
+		api.PUT("/urls/:id/destination",
+			auth.Middleware(auth.JWTService),
+			urlHandler.UpdateDestination,
+		)
+
+		api.PUT("/urls/:id/tags",
+			auth.Middleware(auth.JWTService),
+			urlHandler.UpdateTags,
+		)
+
+		api.POST("/urls/:id/qr",
+			auth.Middleware(auth.JWTService),
+			urlHandler.RegenerateQR,
+		)
+
+		api.GET("/urls/:id/qr",
+			auth.Middleware(auth.JWTService),
+			urlHandler.DownloadQR,
+		)
+
+		api.GET("/urls/:id/nfc",
+			auth.Middleware(auth.JWTService),
+			urlHandler.ExportNFC,
+		)
+
+		api.POST("/urls/qr-batch",
+			auth.Middleware(auth.JWTService),
+			urlHandler.BatchQR,
+		)
+
+		api.POST("/domains",
+			auth.Middleware(auth.JWTService),
+			domainHandler.Register,
+		)
+
+		api.GET("/domains",
+			auth.Middleware(auth.JWTService),
+			domainHandler.List,
+		)
+
+		api.POST("/domains/:id/verify",
+			auth.Middleware(auth.JWTService),
+			domainHandler.Verify,
+		)
+
+		api.DELETE("/domains/:id",
+			auth.Middleware(auth.JWTService),
+			domainHandler.Delete,
+		)
+
+		api.POST("/workspaces",
+			auth.Middleware(auth.JWTService),
+			workspaceHandler.Create,
+		)
+
+		api.GET("/workspaces",
+			auth.Middleware(auth.JWTService),
+			workspaceHandler.List,
+		)
+
+		api.DELETE("/workspaces/:id",
+			auth.Middleware(auth.JWTService),
+			workspaceHandler.Delete,
+		)
+
+		api.GET("/workspaces/:id/members",
+			auth.Middleware(auth.JWTService),
+			workspaceHandler.Members,
+		)
+
+		api.POST("/workspaces/:id/members",
+			auth.Middleware(auth.JWTService),
+			workspaceHandler.AddMember,
+		)
+
+		api.PUT("/workspaces/:id/members/:userId",
+			auth.Middleware(auth.JWTService),
+			workspaceHandler.UpdateMemberRole,
+		)
+
+		api.DELETE("/workspaces/:id/members/:userId",
+			auth.Middleware(auth.JWTService),
+			workspaceHandler.RemoveMember,
+		)
+
+		api.POST("/transfers",
+			auth.Middleware(auth.JWTService),
+			transferHandler.Create,
+		)
+
+		api.GET("/transfers",
+			auth.Middleware(auth.JWTService),
+			transferHandler.List,
+		)
+
+		api.POST("/transfers/:id/accept",
+			auth.Middleware(auth.JWTService),
+			transferHandler.Accept,
+		)
+
+		api.POST("/transfers/:id/decline",
+			auth.Middleware(auth.JWTService),
+			transferHandler.Decline,
+		)
+
+		api.POST("/transfers/:id/cancel",
+			auth.Middleware(auth.JWTService),
+			transferHandler.Cancel,
+		)
+
+		api.POST("/imports",
+			auth.Middleware(auth.JWTService),
+			importHandler.Create,
+		)
+
+		api.GET("/imports",
+			auth.Middleware(auth.JWTService),
+			importHandler.List,
+		)
+
+		api.GET("/imports/:id",
+			auth.Middleware(auth.JWTService),
+			importHandler.Get,
+		)
+
+		api.GET("/exports",
+			auth.Middleware(auth.JWTService),
+			exportHandler.Download,
+		)
+
+		api.GET("/analytics/clicks",
+			auth.Middleware(auth.JWTService),
+			analyticsHandler.ClickSeries,
+		)
+
+		api.GET("/analytics/sources",
+			auth.Middleware(auth.JWTService),
+			analyticsHandler.Sources,
+		)
+
+		api.GET("/analytics/devices",
+			auth.Middleware(auth.JWTService),
+			analyticsHandler.Devices,
+		)
+
+		api.GET("/analytics/geo",
+			auth.Middleware(auth.JWTService),
+			analyticsHandler.Geo,
+		)
+
+		api.POST("/payloads",
+			auth.Middleware(auth.JWTService),
+			payloadHandler.Create,
+		)
+
+		api.GET("/payloads",
+			auth.Middleware(auth.JWTService),
+			payloadHandler.List,
+		)
+
+		api.GET("/payloads/:id",
+			auth.Middleware(auth.JWTService),
+			payloadHandler.Get,
+		)
+
+		api.PUT("/payloads/:id",
+			auth.Middleware(auth.JWTService),
+			payloadHandler.Update,
+		)
+
+		api.DELETE("/payloads/:id",
+			auth.Middleware(auth.JWTService),
+			payloadHandler.Delete,
+		)
+
+		api.GET("/payloads/:id/qr",
+			auth.Middleware(auth.JWTService),
+			payloadHandler.DownloadQR,
+		)
+
+		api.GET("/payloads/files/:file", payloadHandler.File)
+
+		api.GET("/notifications",
+			auth.Middleware(auth.JWTService),
+			notificationHandler.List,
+		)
+
+		api.POST("/notifications/:id/read",
+			auth.Middleware(auth.JWTService),
+			notificationHandler.MarkRead,
+		)
+
+		api.GET("/admin/blocked-codes",
+			auth.Middleware(auth.JWTService),
+			auth.AdminMiddleware(userRepo),
+			blocklistHandler.List,
+		)
+
+		api.POST("/admin/blocked-codes",
+			auth.Middleware(auth.JWTService),
+			auth.AdminMiddleware(userRepo),
+			blocklistHandler.Add,
+		)
+
+		api.DELETE("/admin/blocked-codes/:id",
+			auth.Middleware(auth.JWTService),
+			auth.AdminMiddleware(userRepo),
+			blocklistHandler.Remove,
+		)
 	}
 
 	r.GET("/:code", urlHandler.Redirect)
+	r.GET("/:code/qr", urlHandler.PublicQR)
 
 	log.Println("🚀 Server running at :" + port)
 	r.Run(":" + port)
@@ -124,4 +463,152 @@ func mustGetEnv(key string) string {
 		log.Fatalf("❌ Missing env var: %s", key)
 	}
 	return value
-}
\ No newline at end of file
+}
+
+// clickSalt is the key visitor IPs are hashed with. Without CLICK_IP_SALT a
+// random one is used, so hashes only match within one process lifetime.
+func clickSalt() []byte {
+	if salt := os.Getenv("CLICK_IP_SALT"); salt != "" {
+		return []byte(salt)
+	}
+	log.Printf("⚠️ CLICK_IP_SALT not set, using a random salt: unique visitors reset on restart")
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		log.Fatal("❌ Failed to generate click salt:", err)
+	}
+	return salt
+}
+
+// configureClientIP decides whose forwarded-for headers are believed. Client
+// IPs feed click analytics, so by default none are: set TRUSTED_PROXIES to
+// the proxies' CIDRs, or TRUSTED_PLATFORM (fly, cloudflare, appengine, or a
+// header name) when the host sets the client IP itself.
+func configureClientIP(r *gin.Engine) {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		log.Fatal("❌ Invalid TRUSTED_PROXIES:", err)
+	}
+
+	switch platform := os.Getenv("TRUSTED_PLATFORM"); strings.ToLower(platform) {
+	case "":
+	case "fly":
+		r.TrustedPlatform = gin.PlatformFlyIO
+	case "cloudflare":
+		r.TrustedPlatform = gin.PlatformCloudflare
+	case "appengine":
+		r.TrustedPlatform = gin.PlatformGoogleAppEngine
+	default:
+		r.TrustedPlatform = platform
+	}
+}
+
+// userAgentParser reads UA_RULES_FILE when set, so the device rules can be
+// updated without a new build, and falls back to the embedded rules.
+func userAgentParser() *useragent.Parser {
+	path := os.Getenv("UA_RULES_FILE")
+	if path == "" {
+		return useragent.Default()
+	}
+	p, err := useragent.Load(path)
+	if err != nil {
+		log.Fatal("❌ Failed to load User-Agent rules:", err)
+	}
+	return p
+}
+
+// geoIPDatabase loads GEOIP_DB_FILE, or returns nil when it is not set and
+// clicks are not located.
+func geoIPDatabase() *geoip.DB {
+	path := os.Getenv("GEOIP_DB_FILE")
+	if path == "" {
+		log.Printf("⚠️ GEOIP_DB_FILE not set, clicks will not be located")
+		return nil
+	}
+	db, err := geoip.Open(path)
+	if err != nil {
+		log.Fatal("❌ Failed to load GeoIP database:", err)
+	}
+	return db
+}
+
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Fatalf("❌ Invalid number for %s: %q", key, value)
+	}
+	return n
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Fatalf("❌ Invalid duration for %s: %q", key, value)
+	}
+	return d
+}
+
+// newStorage picks the QR image store from STORAGE_DRIVER. Without it,
+// Cloudinary is used when configured and local files otherwise, so a fresh
+// checkout runs without any cloud account.
+func newStorage(r *gin.Engine, port string) storage.Storage {
+	driver := os.Getenv("STORAGE_DRIVER")
+	if driver == "" {
+		driver = "local"
+		if os.Getenv("CLOUDINARY_CLOUD_NAME") != "" {
+			driver = "cloudinary"
+		}
+	}
+
+	var store storage.Storage
+	var err error
+	switch driver {
+	case "cloudinary":
+		store, err = storage.NewCloudinary(
+			mustGetEnv("CLOUDINARY_CLOUD_NAME"),
+			mustGetEnv("CLOUDINARY_API_KEY"),
+			mustGetEnv("CLOUDINARY_API_SECRET"),
+		)
+	case "s3":
+		store, err = storage.NewS3(storage.S3Config{
+			Endpoint:        mustGetEnv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          mustGetEnv("S3_BUCKET"),
+			AccessKeyID:     mustGetEnv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: mustGetEnv("S3_SECRET_ACCESS_KEY"),
+			PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+			PathStyle:       os.Getenv("S3_PATH_STYLE") != "false",
+		}, &http.Client{Timeout: 30 * time.Second})
+	case "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "./data/files"
+		}
+		baseURL := os.Getenv("STORAGE_PUBLIC_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:" + port + "/files"
+		}
+		store, err = storage.NewLocal(dir, baseURL)
+		// The API serves local files itself.
+		r.Static("/files", dir)
+	default:
+		log.Fatalf("❌ Unknown STORAGE_DRIVER %q (use local, s3 or cloudinary)", driver)
+	}
+	if err != nil {
+		log.Fatalf("❌ Storage init error (%s): %v", driver, err)
+	}
+	log.Printf("✅ Using %s storage for QR images", driver)
+	return store
+}
@@ -6,3 +6,244 @@ CREATE TABLE IF NOT EXISTS urls (
 );
 
 CREATE INDEX idx_urls_short_code ON urls(short_code);
+
+
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(255) NOT NULL UNIQUE,
+    password TEXT NOT NULL
+);
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS user_id INT REFERENCES users(id);
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS qr_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS clicks (
+    id SERIAL PRIMARY KEY,
+    url_id INT NOT NULL REFERENCES urls(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_clicks_url_id ON clicks(url_id);
+
+-- Paused links keep their row and click history but are not redirected.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS enabled BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- expires_at is optional: NULL means the link never expires.
+ALTER TABLE urls ALTER COLUMN expires_at DROP NOT NULL;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS expired_behavior VARCHAR(16) NOT NULL DEFAULT 'not_found';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS fallback_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS expired_message TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS expiry_notified_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    kind VARCHAR(32) NOT NULL,
+    url_id INT REFERENCES urls(id) ON DELETE SET NULL,
+    message TEXT NOT NULL,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, created_at DESC);
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS redirect_status SMALLINT NOT NULL DEFAULT 302;
+
+CREATE TABLE IF NOT EXISTS domains (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    hostname VARCHAR(253) NOT NULL UNIQUE,
+    verification_token VARCHAR(64) NOT NULL,
+    verified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Short codes are unique per domain; NULL domain_id is the default host.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS domain_id INT REFERENCES domains(id);
+ALTER TABLE urls DROP CONSTRAINT IF EXISTS urls_short_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_domain_short_code ON urls(COALESCE(domain_id, 0), short_code);
+
+CREATE TABLE IF NOT EXISTS workspaces (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    owner_id INT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id INT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id),
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'admin', 'editor', 'viewer')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members(user_id);
+
+-- Links with a workspace_id belong to the workspace; user_id is the creator.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS workspace_id INT REFERENCES workspaces(id);
+CREATE INDEX IF NOT EXISTS idx_urls_workspace_id ON urls(workspace_id);
+
+CREATE TABLE IF NOT EXISTS url_transfers (
+    id SERIAL PRIMARY KEY,
+    from_user_id INT NOT NULL REFERENCES users(id),
+    to_user_id INT REFERENCES users(id),
+    to_workspace_id INT REFERENCES workspaces(id),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    responded_at TIMESTAMP,
+    CHECK ((to_user_id IS NULL) <> (to_workspace_id IS NULL))
+);
+
+CREATE TABLE IF NOT EXISTS url_transfer_items (
+    transfer_id INT NOT NULL REFERENCES url_transfers(id) ON DELETE CASCADE,
+    url_id INT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    from_workspace_id INT REFERENCES workspaces(id),
+    PRIMARY KEY (transfer_id, url_id)
+);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id SERIAL PRIMARY KEY,
+    actor_id INT NOT NULL REFERENCES users(id),
+    action VARCHAR(64) NOT NULL,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id INT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE urls ALTER COLUMN short_code TYPE VARCHAR(64);
+
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id INT REFERENCES workspaces(id) ON DELETE CASCADE,
+    domain_id INT REFERENCES domains(id) ON DELETE SET NULL,
+    format VARCHAR(32) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'queued',
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    created INT NOT NULL DEFAULT 0,
+    duplicates INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    conflicts JSONB NOT NULL DEFAULT '[]',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+-- Expired links removed by the reaper in archive mode.
+CREATE TABLE IF NOT EXISTS archived_urls (
+    id INT PRIMARY KEY,
+    user_id INT,
+    workspace_id INT,
+    short_code VARCHAR(64) NOT NULL,
+    original_url TEXT NOT NULL,
+    clicks INT NOT NULL DEFAULT 0,
+    data JSONB NOT NULL,
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_urls_expires_at ON urls(expires_at) WHERE expires_at IS NOT NULL;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Admin-managed additions to the built-in reserved and profanity lists.
+CREATE TABLE IF NOT EXISTS blocked_codes (
+    id SERIAL PRIMARY KEY,
+    word VARCHAR(64) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (word, kind)
+);
+
+-- Per-link QR styling (size, error correction, quiet zone, colors, logo).
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS qr_style JSONB NOT NULL DEFAULT '{"size":256,"error_correction":"M","quiet_zone":4,"foreground":"#000000","background":"#ffffff"}';
+
+-- Background QR uploads. Existing links keep serving their stored image or
+-- the on-demand endpoint; new links start out pending.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS qr_status VARCHAR(16) NOT NULL DEFAULT 'ready';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS qr_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS qr_next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS qr_error TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_urls_qr_pending ON urls(qr_next_attempt_at) WHERE qr_status = 'pending';
+
+-- Where each click came from: 'link' for the shared short URL, 'qr' for scans,
+-- 'nfc' for tag taps.
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS source VARCHAR(16) NOT NULL DEFAULT 'link';
+
+-- Typed QR codes (vCard, Wi-Fi, calendar event). Dynamic ones are served as a
+-- file behind a short link, looked up by token.
+CREATE TABLE IF NOT EXISTS qr_payloads (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    workspace_id INT REFERENCES workspaces(id),
+    type VARCHAR(16) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    content JSONB NOT NULL,
+    dynamic BOOLEAN NOT NULL DEFAULT FALSE,
+    url_id INT REFERENCES urls(id) ON DELETE SET NULL,
+    token VARCHAR(32) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_qr_payloads_user ON qr_payloads(user_id);
+
+-- Click details captured at redirect time. Visitor IPs are stored only as a
+-- salted hash.
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS referrer TEXT NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS ip_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS accept_language TEXT NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS query_params JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS destination TEXT NOT NULL DEFAULT '';
+
+-- Device, browser and OS read from the User-Agent when a click is recorded.
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS device_type VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS browser VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS browser_version VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS os VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS os_version VARCHAR(32) NOT NULL DEFAULT '';
+
+-- Where a click came from, looked up from its IP in a local GeoIP database.
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS country VARCHAR(2) NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS region VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS city VARCHAR(100) NOT NULL DEFAULT '';
+
+-- When a link last answered with a 301/308. Browsers may cache those, so
+-- the destination stays locked until REDIRECT_CACHE_MAX_AGE has passed.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS permanent_served_at TIMESTAMP;
+
+-- Bumped by every restyle so an upload of an older style is never marked
+-- ready.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS qr_version INT NOT NULL DEFAULT 0;
+
+-- Any number of users may claim a hostname; only one can verify it.
+ALTER TABLE domains DROP CONSTRAINT IF EXISTS domains_hostname_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_verified_hostname ON domains(hostname) WHERE verified_at IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_user_hostname ON domains(user_id, hostname);
+
+-- Analytics queries filter each link's clicks by time range.
+CREATE INDEX IF NOT EXISTS idx_clicks_url_created ON clicks(url_id, created_at);
+
+-- Site admins can hand over a departed user's links; requested_by is who
+-- asked, from_user_id still the owner.
+ALTER TABLE url_transfers ADD COLUMN IF NOT EXISTS requested_by INT REFERENCES users(id);
+CREATE INDEX IF NOT EXISTS idx_url_transfers_requested_by ON url_transfers(requested_by);
+
+-- Deleting a workspace takes transfers into it along, and leaves finished
+-- transfers out of it with their items' workspace cleared.
+ALTER TABLE url_transfers DROP CONSTRAINT IF EXISTS url_transfers_to_workspace_id_fkey;
+ALTER TABLE url_transfers ADD CONSTRAINT url_transfers_to_workspace_id_fkey
+    FOREIGN KEY (to_workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE url_transfer_items DROP CONSTRAINT IF EXISTS url_transfer_items_from_workspace_id_fkey;
+ALTER TABLE url_transfer_items ADD CONSTRAINT url_transfer_items_from_workspace_id_fkey
+    FOREIGN KEY (from_workspace_id) REFERENCES workspaces(id) ON DELETE SET NULL;
@@ -0,0 +1,215 @@
+package analytics
+
+import (
+	"strings"
+	"url-shortener/internal/click"
+)
+
+// Channels group clicks by how visitors reached a link.
+const (
+	ChannelQR       = "qr"
+	ChannelNFC      = "nfc"
+	ChannelEmail    = "email"
+	ChannelSocial   = "social"
+	ChannelSearch   = "search"
+	ChannelReferral = "referral"
+	ChannelDirect   = "direct"
+)
+
+// domainRules map referring hosts to channels. A rule matches the host and
+// its subdomains; a trailing dot matches the name under any public suffix
+// (google. matches google.com and www.google.co.uk). Rules are tried in
+// order, so webmail hosts come before the search engines sharing a brand.
+var domainRules = []struct {
+	domain  string
+	channel string
+}{
+	{"mail.google.com", ChannelEmail},
+	{"com.google.android.gm", ChannelEmail},
+	{"outlook.live.com", ChannelEmail},
+	{"outlook.office.com", ChannelEmail},
+	{"outlook.office365.com", ChannelEmail},
+	{"mail.yahoo.com", ChannelEmail},
+	{"mail.proton.me", ChannelEmail},
+	{"mail.aol.com", ChannelEmail},
+	{"mail.zoho.com", ChannelEmail},
+	{"mail.yandex.ru", ChannelEmail},
+	{"com.microsoft.office.outlook", ChannelEmail},
+
+	{"facebook.com", ChannelSocial},
+	{"fb.com", ChannelSocial},
+	{"fb.me", ChannelSocial},
+	{"com.facebook.katana", ChannelSocial},
+	{"messenger.com", ChannelSocial},
+	{"instagram.com", ChannelSocial},
+	{"com.instagram.android", ChannelSocial},
+	{"twitter.com", ChannelSocial},
+	{"x.com", ChannelSocial},
+	{"t.co", ChannelSocial},
+	{"linkedin.com", ChannelSocial},
+	{"lnkd.in", ChannelSocial},
+	{"com.linkedin.android", ChannelSocial},
+	{"reddit.com", ChannelSocial},
+	{"pinterest.", ChannelSocial},
+	{"pin.it", ChannelSocial},
+	{"tiktok.com", ChannelSocial},
+	{"youtube.com", ChannelSocial},
+	{"youtu.be", ChannelSocial},
+	{"whatsapp.com", ChannelSocial},
+	{"wa.me", ChannelSocial},
+	{"t.me", ChannelSocial},
+	{"telegram.org", ChannelSocial},
+	{"org.telegram.messenger", ChannelSocial},
+	{"snapchat.com", ChannelSocial},
+	{"threads.net", ChannelSocial},
+	{"bsky.app", ChannelSocial},
+	{"mastodon.social", ChannelSocial},
+	{"discord.com", ChannelSocial},
+	{"tumblr.com", ChannelSocial},
+	{"quora.com", ChannelSocial},
+	{"vk.com", ChannelSocial},
+	{"weibo.com", ChannelSocial},
+	{"news.ycombinator.com", ChannelSocial},
+
+	{"google.", ChannelSearch},
+	{"com.google.android.googlequicksearchbox", ChannelSearch},
+	{"bing.com", ChannelSearch},
+	{"duckduckgo.com", ChannelSearch},
+	{"search.yahoo.com", ChannelSearch},
+	{"yahoo.", ChannelSearch},
+	{"baidu.com", ChannelSearch},
+	{"yandex.", ChannelSearch},
+	{"ecosia.org", ChannelSearch},
+	{"search.brave.com", ChannelSearch},
+	{"startpage.com", ChannelSearch},
+	{"qwant.com", ChannelSearch},
+	{"naver.com", ChannelSearch},
+	{"ask.com", ChannelSearch},
+}
+
+// sourceNames classify utm_source values that are names rather than hosts.
+var sourceNames = map[string]string{
+	"newsletter": ChannelEmail,
+	"email":      ChannelEmail,
+	"mailchimp":  ChannelEmail,
+	"sendgrid":   ChannelEmail,
+	"gmail":      ChannelEmail,
+	"outlook":    ChannelEmail,
+	"facebook":   ChannelSocial,
+	"fb":         ChannelSocial,
+	"instagram":  ChannelSocial,
+	"ig":         ChannelSocial,
+	"twitter":    ChannelSocial,
+	"x":          ChannelSocial,
+	"linkedin":   ChannelSocial,
+	"reddit":     ChannelSocial,
+	"pinterest":  ChannelSocial,
+	"tiktok":     ChannelSocial,
+	"youtube":    ChannelSocial,
+	"whatsapp":   ChannelSocial,
+	"telegram":   ChannelSocial,
+	"snapchat":   ChannelSocial,
+	"threads":    ChannelSocial,
+	"bluesky":    ChannelSocial,
+	"mastodon":   ChannelSocial,
+	"discord":    ChannelSocial,
+	"google":     ChannelSearch,
+	"bing":       ChannelSearch,
+	"duckduckgo": ChannelSearch,
+	"yahoo":      ChannelSearch,
+	"baidu":      ChannelSearch,
+	"yandex":     ChannelSearch,
+	"ecosia":     ChannelSearch,
+}
+
+// mediumNames classify utm_medium values, which name the channel outright.
+var mediumNames = map[string]string{
+	"email":        ChannelEmail,
+	"e-mail":       ChannelEmail,
+	"newsletter":   ChannelEmail,
+	"social":       ChannelSocial,
+	"social-media": ChannelSocial,
+	"social_media": ChannelSocial,
+	"paid-social":  ChannelSocial,
+	"paid_social":  ChannelSocial,
+	"sm":           ChannelSocial,
+	"organic":      ChannelSearch,
+	"search":       ChannelSearch,
+	"cpc":          ChannelSearch,
+	"ppc":          ChannelSearch,
+	"paidsearch":   ChannelSearch,
+	"paid-search":  ChannelSearch,
+	"paid_search":  ChannelSearch,
+	"qr":           ChannelQR,
+	"qrcode":       ChannelQR,
+	"qr-code":      ChannelQR,
+	"qr_code":      ChannelQR,
+	"print":        ChannelQR,
+	"nfc":          ChannelNFC,
+}
+
+// classify picks the channel of a group of clicks. Scans and taps are
+// marked by the short link itself, so they win; then an explicit
+// utm_medium, the referring host, and utm_source. Clicks with neither a
+// referrer nor UTM tags are direct.
+func classify(c SourceCount) string {
+	switch c.Source {
+	case click.SourceQR:
+		return ChannelQR
+	case click.SourceNFC:
+		return ChannelNFC
+	}
+	if ch, ok := mediumNames[c.UTMMedium]; ok {
+		return ch
+	}
+	if ch := domainChannel(c.ReferrerHost); ch != "" {
+		return ch
+	}
+	if ch, ok := sourceNames[c.UTMSource]; ok {
+		return ch
+	}
+	if ch := domainChannel(c.UTMSource); ch != "" {
+		return ch
+	}
+	if c.ReferrerHost != "" || c.UTMSource != "" || c.UTMMedium != "" {
+		return ChannelReferral
+	}
+	return ChannelDirect
+}
+
+// domainChannel classifies a host by domainRules, or returns "".
+func domainChannel(host string) string {
+	if host == "" {
+		return ""
+	}
+	for _, rule := range domainRules {
+		if matchDomain(host, rule.domain) {
+			return rule.channel
+		}
+	}
+	return ""
+}
+
+func matchDomain(host, domain string) bool {
+	if name, ok := strings.CutSuffix(domain, "."); ok {
+		labels := strings.Split(host, ".")
+		for i, label := range labels {
+			if label != name || i == len(labels)-1 {
+				continue
+			}
+			// Only short labels, like com or co.uk, may follow the name.
+			suffix := true
+			for _, l := range labels[i+1:] {
+				if len(l) > 3 {
+					suffix = false
+					break
+				}
+			}
+			if suffix {
+				return true
+			}
+		}
+		return false
+	}
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,61 @@
+package analytics
+
+import "fmt"
+
+// UnknownName labels clicks whose User-Agent did not say.
+const UnknownName = "unknown"
+
+func (s *service) Devices(userID int64, in BreakdownInput) (*Devices, error) {
+	scope, rng, err := s.breakdown(userID, &in)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.CountByAgent(scope, rng.From, rng.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clicks: %w", err)
+	}
+
+	devices := map[string]int{}
+	browsers := map[string]int{}
+	browserVersions := map[string]int{}
+	systems := map[string]int{}
+	systemVersions := map[string]int{}
+	total := 0
+	for _, c := range counts {
+		total += c.Clicks
+		devices[orUnknown(c.Device)] += c.Clicks
+		browsers[orUnknown(c.Browser)] += c.Clicks
+		systems[orUnknown(c.OS)] += c.Clicks
+		add(browserVersions, versionName(c.Browser, c.BrowserMajor), c.Clicks)
+		add(systemVersions, versionName(c.OS, c.OSMajor), c.Clicks)
+	}
+
+	return &Devices{
+		From:             rng.From,
+		To:               rng.To,
+		Source:           in.Source,
+		Total:            total,
+		DeviceTypes:      shares(devices, total, 0),
+		Browsers:         shares(browsers, total, in.Limit),
+		BrowserVersions:  shares(browserVersions, total, in.Limit),
+		OperatingSystems: shares(systems, total, in.Limit),
+		OSVersions:       shares(systemVersions, total, in.Limit),
+	}, nil
+}
+
+func orUnknown(name string) string {
+	if name == "" {
+		return UnknownName
+	}
+	return name
+}
+
+// versionName joins a family and its major version; it is empty when the
+// family is unknown, which leaves the click out of version lists.
+func versionName(family, major string) string {
+	if family == "" || major == "" {
+		return family
+	}
+	return family + " " + major
+}
@@ -0,0 +1,51 @@
+package analytics
+
+import "fmt"
+
+func (s *service) Geo(userID int64, in BreakdownInput) (*Geo, error) {
+	scope, rng, err := s.breakdown(userID, &in)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.CountByGeo(scope, rng.From, rng.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clicks: %w", err)
+	}
+
+	countries := map[string]int{}
+	regions := map[string]int{}
+	cities := map[string]int{}
+	total := 0
+	for _, c := range counts {
+		total += c.Clicks
+		countries[orUnknown(c.Country)] += c.Clicks
+		if c.Country == "" {
+			continue
+		}
+		// Names repeat across countries, so they are qualified.
+		if c.Region != "" {
+			add(regions, c.Region+", "+c.Country, c.Clicks)
+		}
+		if c.City != "" {
+			add(cities, placeName(c.City, c.Region, c.Country), c.Clicks)
+		}
+	}
+
+	return &Geo{
+		From:      rng.From,
+		To:        rng.To,
+		Source:    in.Source,
+		Total:     total,
+		Countries: shares(countries, total, in.Limit),
+		Regions:   shares(regions, total, in.Limit),
+		Cities:    shares(cities, total, in.Limit),
+	}, nil
+}
+
+func placeName(city, region, country string) string {
+	if region == "" {
+		return city + ", " + country
+	}
+	return city + ", " + region + ", " + country
+}
@@ -0,0 +1,198 @@
+package analytics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"url-shortener/internal/url"
+	"url-shortener/internal/workspace"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, url.ErrURLNotFound), errors.Is(err, workspace.ErrWorkspaceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, url.ErrForbidden), errors.Is(err, workspace.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// optionalID reads an optional numeric query parameter.
+func optionalID(c *gin.Context, field string) (*int64, error) {
+	raw := c.Query(field)
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid " + field)
+	}
+	return &id, nil
+}
+
+// GET /api/analytics/clicks?url_id=|tag=&workspace_id=&interval=hour|day|week|month&tz=&from=&to=&compare=true
+func (h *Handler) ClickSeries(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	in := SeriesInput{
+		Tag:      c.Query("tag"),
+		Interval: c.Query("interval"),
+		TimeZone: c.Query("tz"),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+		Compare:  c.Query("compare") == "true",
+	}
+	var err error
+	if in.WorkspaceID, err = optionalID(c, "workspace_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if in.URLID, err = optionalID(c, "url_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series, err := h.service.ClickSeries(userID.(int64), in)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// GET /api/analytics/sources?url_id=|tag=&workspace_id=&source=link|qr|nfc&tz=&from=&to=&limit=
+func (h *Handler) Sources(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	in := BreakdownInput{
+		Tag:      c.Query("tag"),
+		Source:   c.Query("source"),
+		TimeZone: c.Query("tz"),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+	}
+	var err error
+	if in.WorkspaceID, err = optionalID(c, "workspace_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if in.URLID, err = optionalID(c, "url_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if raw := c.Query("limit"); raw != "" {
+		if in.Limit, err = strconv.Atoi(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+
+	sources, err := h.service.Sources(userID.(int64), in)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sources)
+}
+
+// GET /api/analytics/devices?url_id=|tag=&workspace_id=&source=link|qr|nfc&tz=&from=&to=&limit=
+func (h *Handler) Devices(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	in := BreakdownInput{
+		Tag:      c.Query("tag"),
+		Source:   c.Query("source"),
+		TimeZone: c.Query("tz"),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+	}
+	var err error
+	if in.WorkspaceID, err = optionalID(c, "workspace_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if in.URLID, err = optionalID(c, "url_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if raw := c.Query("limit"); raw != "" {
+		if in.Limit, err = strconv.Atoi(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+
+	devices, err := h.service.Devices(userID.(int64), in)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, devices)
+}
+
+// GET /api/analytics/geo?url_id=|tag=&workspace_id=&source=link|qr|nfc&tz=&from=&to=&limit=
+func (h *Handler) Geo(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	in := BreakdownInput{
+		Tag:      c.Query("tag"),
+		Source:   c.Query("source"),
+		TimeZone: c.Query("tz"),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+	}
+	var err error
+	if in.WorkspaceID, err = optionalID(c, "workspace_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if in.URLID, err = optionalID(c, "url_id"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if raw := c.Query("limit"); raw != "" {
+		if in.Limit, err = strconv.Atoi(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+
+	geo, err := h.service.Geo(userID.(int64), in)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, geo)
+}
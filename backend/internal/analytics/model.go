@@ -0,0 +1,135 @@
+package analytics
+
+import "time"
+
+const (
+	IntervalHour  = "hour"
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// Scope selects whose clicks are counted: one link, the links carrying a
+// tag, or every link of an account (the user's personal space, or the
+// workspace when WorkspaceID is set). Source, when set, keeps only clicks
+// of that click source.
+type Scope struct {
+	UserID      int64
+	WorkspaceID *int64
+	URLID       *int64
+	Tag         string
+	Source      string
+}
+
+// Range is a half-open time range [From, To) read in Location.
+type Range struct {
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+}
+
+type Bucket struct {
+	Start  time.Time `json:"start"`
+	Clicks int       `json:"clicks"`
+}
+
+type Period struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Total   int       `json:"total"`
+	Buckets []Bucket  `json:"buckets"`
+}
+
+// Series is clicks over time, optionally with the period of the same length
+// just before it for comparison.
+type Series struct {
+	Interval string `json:"interval"`
+	TimeZone string `json:"time_zone"`
+	Period
+	Previous *Period `json:"previous,omitempty"`
+	// Change is the percentage change of Total against Previous; nil when
+	// the previous period had no clicks.
+	Change *float64 `json:"change,omitempty"`
+}
+
+// SourceCount is the number of clicks sharing a click source, referring
+// host and UTM tags. Hosts and tags are lower-case; missing ones are empty.
+type SourceCount struct {
+	Source       string
+	ReferrerHost string
+	UTMSource    string
+	UTMMedium    string
+	UTMCampaign  string
+	Clicks       int
+}
+
+// Share is one row of a breakdown; Percent is of all clicks in the range.
+// Other marks the row summing the entries beyond the top of a list, since a
+// referrer or UTM tag may itself be named OtherName.
+type Share struct {
+	Name    string  `json:"name"`
+	Clicks  int     `json:"clicks"`
+	Percent float64 `json:"percent"`
+	Other   bool    `json:"other,omitempty"`
+}
+
+// Sources breaks the clicks in a range down by how visitors arrived. Lists
+// other than Channels hold the top entries, with the rest summed in a last
+// row marked Other; clicks without a referrer or a UTM tag are left out of that list.
+type Sources struct {
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	Total        int       `json:"total"`
+	Channels     []Share   `json:"channels"`
+	Referrers    []Share   `json:"referrers"`
+	UTMSources   []Share   `json:"utm_sources"`
+	UTMMediums   []Share   `json:"utm_mediums"`
+	UTMCampaigns []Share   `json:"utm_campaigns"`
+}
+
+// AgentCount is the number of clicks sharing a device type, browser and OS,
+// with versions cut to the major number.
+type AgentCount struct {
+	Device       string
+	Browser      string
+	BrowserMajor string
+	OS           string
+	OSMajor      string
+	Clicks       int
+}
+
+// Devices breaks the clicks in a range down by what visitors used, as read
+// from their User-Agent. Version lists name the family with its major
+// version, as in "iOS 17".
+type Devices struct {
+	From             time.Time `json:"from"`
+	To               time.Time `json:"to"`
+	Source           string    `json:"source,omitempty"`
+	Total            int       `json:"total"`
+	DeviceTypes      []Share   `json:"device_types"`
+	Browsers         []Share   `json:"browsers"`
+	BrowserVersions  []Share   `json:"browser_versions"`
+	OperatingSystems []Share   `json:"operating_systems"`
+	OSVersions       []Share   `json:"os_versions"`
+}
+
+// GeoCount is the number of clicks from one place.
+type GeoCount struct {
+	Country string
+	Region  string
+	City    string
+	Clicks  int
+}
+
+// Geo breaks the clicks in a range down by where visitors were, as found in
+// the GeoIP database when each click was recorded. Countries are ISO codes;
+// regions and cities are named with their country, as in "Bavaria, DE".
+type Geo struct {
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Source    string    `json:"source,omitempty"`
+	Total     int       `json:"total"`
+	Countries []Share   `json:"countries"`
+	Regions   []Share   `json:"regions"`
+	Cities    []Share   `json:"cities"`
+}
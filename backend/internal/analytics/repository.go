@@ -0,0 +1,172 @@
+package analytics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+)
+
+type Repository interface {
+	// CountByBucket counts clicks per interval, keyed by the bucket's start
+	// as wall-clock time in tz.
+	CountByBucket(scope Scope, interval, tz string, from, to time.Time) (map[time.Time]int, error)
+	// CountBySource counts clicks per source, referring host and UTM tags.
+	CountBySource(scope Scope, from, to time.Time) ([]SourceCount, error)
+	// CountByAgent counts clicks per device type, browser and OS.
+	CountByAgent(scope Scope, from, to time.Time) ([]AgentCount, error)
+	// CountByGeo counts clicks per country, region and city.
+	CountByGeo(scope Scope, from, to time.Time) ([]GeoCount, error)
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+// where builds the filter for clicks c joined to their links u. created_at
+// holds UTC wall-clock time, see click.ClickEvent.
+func where(scope Scope, from, to time.Time, args []any) (string, []any) {
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	cond := "c.created_at >= " + arg(from.UTC()) + " AND c.created_at < " + arg(to.UTC())
+	if scope.URLID != nil {
+		// Access to a single link is checked by the service.
+		cond += " AND u.id = " + arg(*scope.URLID)
+	} else if scope.WorkspaceID != nil {
+		cond += " AND u.workspace_id = " + arg(*scope.WorkspaceID)
+	} else {
+		cond += " AND u.user_id = " + arg(scope.UserID) + " AND u.workspace_id IS NULL"
+	}
+	if scope.Tag != "" {
+		cond += " AND " + arg(scope.Tag) + " = ANY(u.tags)"
+	}
+	if scope.Source != "" {
+		cond += " AND c.source = " + arg(scope.Source)
+	}
+	return cond, args
+}
+
+func (r *repository) CountByBucket(scope Scope, interval, tz string, from, to time.Time) (map[time.Time]int, error) {
+	cond, args := where(scope, from, to, []any{interval, tz})
+	rows, err := r.db.Query(`
+		SELECT date_trunc($1, c.created_at AT TIME ZONE 'UTC' AT TIME ZONE $2) AS bucket, COUNT(*)
+		FROM clicks c
+		JOIN urls u ON u.id = c.url_id
+		WHERE `+cond+`
+		GROUP BY bucket`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[time.Time]int{}
+	for rows.Next() {
+		var bucket time.Time
+		var n int
+		if err := rows.Scan(&bucket, &n); err != nil {
+			return nil, err
+		}
+		counts[wallClock(bucket)] = n
+	}
+	return counts, rows.Err()
+}
+
+func (r *repository) CountBySource(scope Scope, from, to time.Time) ([]SourceCount, error) {
+	cond, args := where(scope, from, to, nil)
+	// Only the host of a referrer is kept: paths can carry search terms or
+	// other personal data.
+	rows, err := r.db.Query(`
+		SELECT c.source,
+			COALESCE(lower(substring(c.referrer FROM '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/?#]*@)?([^/:?#]+)')), '') AS host,
+			lower(trim(COALESCE(c.query_params->'utm_source'->>0, ''))) AS utm_source,
+			lower(trim(COALESCE(c.query_params->'utm_medium'->>0, ''))) AS utm_medium,
+			lower(trim(COALESCE(c.query_params->'utm_campaign'->>0, ''))) AS utm_campaign,
+			COUNT(*)
+		FROM clicks c
+		JOIN urls u ON u.id = c.url_id
+		WHERE `+cond+`
+		GROUP BY 1, 2, 3, 4, 5`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []SourceCount
+	for rows.Next() {
+		var sc SourceCount
+		if err := rows.Scan(&sc.Source, &sc.ReferrerHost, &sc.UTMSource, &sc.UTMMedium, &sc.UTMCampaign, &sc.Clicks); err != nil {
+			return nil, err
+		}
+		counts = append(counts, sc)
+	}
+	return counts, rows.Err()
+}
+
+func (r *repository) CountByAgent(scope Scope, from, to time.Time) ([]AgentCount, error) {
+	cond, args := where(scope, from, to, nil)
+	rows, err := r.db.Query(`
+		SELECT c.device_type, c.browser, split_part(c.browser_version, '.', 1),
+			c.os, split_part(c.os_version, '.', 1), COUNT(*)
+		FROM clicks c
+		JOIN urls u ON u.id = c.url_id
+		WHERE `+cond+`
+		GROUP BY 1, 2, 3, 4, 5`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []AgentCount
+	for rows.Next() {
+		var ac AgentCount
+		if err := rows.Scan(&ac.Device, &ac.Browser, &ac.BrowserMajor, &ac.OS, &ac.OSMajor, &ac.Clicks); err != nil {
+			return nil, err
+		}
+		counts = append(counts, ac)
+	}
+	return counts, rows.Err()
+}
+
+func (r *repository) CountByGeo(scope Scope, from, to time.Time) ([]GeoCount, error) {
+	cond, args := where(scope, from, to, nil)
+	rows, err := r.db.Query(`
+		SELECT c.country, c.region, c.city, COUNT(*)
+		FROM clicks c
+		JOIN urls u ON u.id = c.url_id
+		WHERE `+cond+`
+		GROUP BY 1, 2, 3`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []GeoCount
+	for rows.Next() {
+		var gc GeoCount
+		if err := rows.Scan(&gc.Country, &gc.Region, &gc.City, &gc.Clicks); err != nil {
+			return nil, err
+		}
+		counts = append(counts, gc)
+	}
+	return counts, rows.Err()
+}
+
+// wallClock strips the location so wall-clock times compare equal whatever
+// zone they were read in.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.UTC)
+}
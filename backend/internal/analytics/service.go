@@ -0,0 +1,241 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"url-shortener/internal/url"
+	"url-shortener/internal/workspace"
+
+	// Time zones are looked up by IANA name; embed the database so this
+	// works on hosts without one.
+	_ "time/tzdata"
+)
+
+const maxBuckets = 1000
+
+// SeriesInput is an analytics request as received from clients. From and To
+// are dates (YYYY-MM-DD, To inclusive) or RFC 3339 times; dates are read in
+// TimeZone.
+type SeriesInput struct {
+	WorkspaceID *int64
+	URLID       *int64
+	Tag         string
+	Interval    string
+	TimeZone    string
+	From        string
+	To          string
+	Compare     bool
+}
+
+type Service interface {
+	ClickSeries(userID int64, in SeriesInput) (*Series, error)
+	// Sources breaks clicks down by channel, referring domain and UTM tags.
+	Sources(userID int64, in BreakdownInput) (*Sources, error)
+	// Devices breaks clicks down by device type, browser and OS.
+	Devices(userID int64, in BreakdownInput) (*Devices, error)
+	// Geo breaks clicks down by country, region and city.
+	Geo(userID int64, in BreakdownInput) (*Geo, error)
+}
+
+type service struct {
+	repo             Repository
+	urlService       url.Service
+	workspaceService workspace.Service
+}
+
+func NewService(repo Repository, urlService url.Service, workspaceService workspace.Service) Service {
+	return &service{repo: repo, urlService: urlService, workspaceService: workspaceService}
+}
+
+func (s *service) ClickSeries(userID int64, in SeriesInput) (*Series, error) {
+	scope, err := s.authorize(userID, in.WorkspaceID, in.URLID, in.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.Interval == "" {
+		in.Interval = IntervalDay
+	}
+	switch in.Interval {
+	case IntervalHour, IntervalDay, IntervalWeek, IntervalMonth:
+	default:
+		return nil, errors.New("interval must be one of hour, day, week, month")
+	}
+
+	rng, err := parseRange(in.From, in.To, in.TimeZone, defaultSpan(in.Interval))
+	if err != nil {
+		return nil, err
+	}
+	rng.From = truncate(rng.From, in.Interval)
+
+	series := &Series{Interval: in.Interval, TimeZone: rng.Location.String()}
+	cur, err := s.period(scope, in.Interval, rng)
+	if err != nil {
+		return nil, err
+	}
+	series.Period = *cur
+
+	if in.Compare {
+		prevFrom := rng.From
+		for range cur.Buckets {
+			prevFrom = step(prevFrom, in.Interval, -1)
+		}
+		prevTo := prevFrom.Add(rng.To.Sub(rng.From))
+		if prevTo.After(rng.From) {
+			prevTo = rng.From
+		}
+		prev, err := s.period(scope, in.Interval, Range{From: prevFrom, To: prevTo, Location: rng.Location})
+		if err != nil {
+			return nil, err
+		}
+		series.Previous = prev
+		if prev.Total > 0 {
+			change := math.Round(float64(cur.Total-prev.Total)/float64(prev.Total)*1000) / 10
+			series.Change = &change
+		}
+	}
+	return series, nil
+}
+
+// period counts clicks in rng and lays them out in zero-filled buckets.
+func (s *service) period(scope Scope, interval string, rng Range) (*Period, error) {
+	var starts []time.Time
+	var last time.Time
+	for t := rng.From; t.Before(rng.To); t = step(t, interval, 1) {
+		// Falling back from DST repeats a wall-clock hour; it is one bucket.
+		wall := wallClock(t)
+		if wall.Equal(last) {
+			continue
+		}
+		last = wall
+		if len(starts) == maxBuckets {
+			return nil, fmt.Errorf("too many %s buckets (max %d); use a shorter range or a longer interval", interval, maxBuckets)
+		}
+		starts = append(starts, t)
+	}
+
+	counts, err := s.repo.CountByBucket(scope, interval, rng.Location.String(), rng.From, rng.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clicks: %w", err)
+	}
+
+	p := &Period{From: rng.From, To: rng.To, Buckets: make([]Bucket, len(starts))}
+	for i, start := range starts {
+		n := counts[wallClock(start)]
+		p.Buckets[i] = Bucket{Start: start, Clicks: n}
+		p.Total += n
+	}
+	return p, nil
+}
+
+// authorize checks the caller may view the selected links and resolves the
+// scope to query.
+func (s *service) authorize(userID int64, workspaceID, urlID *int64, tag string) (Scope, error) {
+	scope := Scope{UserID: userID, WorkspaceID: workspaceID, URLID: urlID, Tag: strings.ToLower(strings.TrimSpace(tag))}
+	if urlID != nil && scope.Tag != "" {
+		return scope, errors.New("choose either url_id or tag")
+	}
+
+	if urlID != nil {
+		if _, err := s.urlService.GetURLByID(userID, *urlID); err != nil {
+			return scope, err
+		}
+		return scope, nil
+	}
+	if workspaceID != nil {
+		if _, err := s.workspaceService.Authorize(userID, *workspaceID, workspace.PermView); err != nil {
+			return scope, err
+		}
+	}
+	return scope, nil
+}
+
+// defaultSpan is how far back a series reaches when no start is given.
+func defaultSpan(interval string) func(time.Time) time.Time {
+	return func(to time.Time) time.Time {
+		switch interval {
+		case IntervalHour:
+			return to.Add(-24 * time.Hour)
+		case IntervalWeek:
+			return to.AddDate(0, 0, -7*12)
+		case IntervalMonth:
+			return to.AddDate(-1, 0, 0)
+		}
+		return to.AddDate(0, 0, -30)
+	}
+}
+
+// parseRange reads from and to in the named zone. to defaults to now and
+// from to defaultFrom(to).
+func parseRange(from, to, tz string, defaultFrom func(time.Time) time.Time) (Range, error) {
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return Range{}, fmt.Errorf("unknown time zone %q", tz)
+	}
+	rng := Range{Location: loc, To: time.Now().In(loc)}
+
+	if to != "" {
+		if rng.To, err = parseTime(to, loc, true); err != nil {
+			return rng, errors.New("invalid to: use YYYY-MM-DD or RFC 3339")
+		}
+	}
+	rng.From = defaultFrom(rng.To)
+	if from != "" {
+		if rng.From, err = parseTime(from, loc, false); err != nil {
+			return rng, errors.New("invalid from: use YYYY-MM-DD or RFC 3339")
+		}
+	}
+	if !rng.From.Before(rng.To) {
+		return rng, errors.New("from must be before to")
+	}
+	return rng, nil
+}
+
+// parseTime accepts a date or an RFC 3339 time. A date used as the end of a
+// range includes that whole day.
+func parseTime(value string, loc *time.Location, end bool) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t.In(loc), err
+}
+
+// truncate returns the start of the bucket containing t, as date_trunc does
+// in Postgres: weeks start on Monday.
+func truncate(t time.Time, interval string) time.Time {
+	y, m, d := t.Date()
+	loc := t.Location()
+	switch interval {
+	case IntervalHour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+	case IntervalWeek:
+		return time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, loc)
+	case IntervalMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// step moves t by n buckets. Calendar intervals follow the wall clock so
+// buckets stay aligned across DST changes.
+func step(t time.Time, interval string, n int) time.Time {
+	switch interval {
+	case IntervalHour:
+		return t.Add(time.Duration(n) * time.Hour)
+	case IntervalWeek:
+		return t.AddDate(0, 0, 7*n)
+	case IntervalMonth:
+		return t.AddDate(0, n, 0)
+	}
+	return t.AddDate(0, 0, n)
+}
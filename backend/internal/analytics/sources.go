@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"url-shortener/internal/click"
+)
+
+// Breakdown list sizes.
+const (
+	DefaultTopN = 10
+	MaxTopN     = 100
+)
+
+// OtherName labels the entries summed beyond the top of a list.
+const OtherName = "other"
+
+// BreakdownInput is a sources, devices or geo breakdown request. From and
+// To are read as in SeriesInput; Source keeps only clicks of one click
+// source, so source=qr shows what phones scanned a code; Limit is the
+// length of the top lists.
+type BreakdownInput struct {
+	WorkspaceID *int64
+	URLID       *int64
+	Tag         string
+	Source      string
+	TimeZone    string
+	From        string
+	To          string
+	Limit       int
+}
+
+// breakdown authorizes and validates a breakdown request, filling in the
+// default limit, and returns the scope and range to count clicks in.
+func (s *service) breakdown(userID int64, in *BreakdownInput) (Scope, Range, error) {
+	scope, err := s.authorize(userID, in.WorkspaceID, in.URLID, in.Tag)
+	if err != nil {
+		return Scope{}, Range{}, err
+	}
+	if in.Source != "" && !click.ValidSource(in.Source) {
+		return Scope{}, Range{}, errors.New("source must be one of link, qr, nfc")
+	}
+	scope.Source = in.Source
+	if in.Limit == 0 {
+		in.Limit = DefaultTopN
+	}
+	if in.Limit < 1 || in.Limit > MaxTopN {
+		return Scope{}, Range{}, fmt.Errorf("limit must be between 1 and %d", MaxTopN)
+	}
+
+	rng, err := parseRange(in.From, in.To, in.TimeZone, defaultSpan(IntervalDay))
+	if err != nil {
+		return Scope{}, Range{}, err
+	}
+	return scope, rng, nil
+}
+
+func (s *service) Sources(userID int64, in BreakdownInput) (*Sources, error) {
+	scope, rng, err := s.breakdown(userID, &in)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.CountBySource(scope, rng.From, rng.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clicks: %w", err)
+	}
+
+	channels := map[string]int{}
+	referrers := map[string]int{}
+	utmSources := map[string]int{}
+	utmMediums := map[string]int{}
+	utmCampaigns := map[string]int{}
+	total := 0
+	for _, c := range counts {
+		total += c.Clicks
+		channels[classify(c)] += c.Clicks
+		add(referrers, strings.TrimPrefix(c.ReferrerHost, "www."), c.Clicks)
+		add(utmSources, c.UTMSource, c.Clicks)
+		add(utmMediums, c.UTMMedium, c.Clicks)
+		add(utmCampaigns, c.UTMCampaign, c.Clicks)
+	}
+
+	return &Sources{
+		From:         rng.From,
+		To:           rng.To,
+		Total:        total,
+		Channels:     shares(channels, total, 0),
+		Referrers:    shares(referrers, total, in.Limit),
+		UTMSources:   shares(utmSources, total, in.Limit),
+		UTMMediums:   shares(utmMediums, total, in.Limit),
+		UTMCampaigns: shares(utmCampaigns, total, in.Limit),
+	}, nil
+}
+
+func add(m map[string]int, name string, n int) {
+	if name != "" {
+		m[name] += n
+	}
+}
+
+// shares sorts counts by clicks, keeping the top limit entries and summing
+// the rest in a row marked Other. A limit of 0 keeps every entry.
+func shares(counts map[string]int, total, limit int) []Share {
+	list := make([]Share, 0, len(counts))
+	for name, n := range counts {
+		list = append(list, Share{Name: name, Clicks: n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Clicks != list[j].Clicks {
+			return list[i].Clicks > list[j].Clicks
+		}
+		return list[i].Name < list[j].Name
+	})
+
+	if limit > 0 && len(list) > limit {
+		other := Share{Name: OtherName, Other: true}
+		for _, sh := range list[limit:] {
+			other.Clicks += sh.Clicks
+		}
+		list = append(list[:limit], other)
+	}
+	for i := range list {
+		list[i].Percent = percent(list[i].Clicks, total)
+	}
+	return list
+}
+
+// percent is n as a percentage of total, rounded to one decimal.
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(total)*1000) / 10
+}
@@ -0,0 +1,8 @@
+package audit
+
+// Actions recorded in audit_log.
+const (
+	ActionTransferRequested = "transfer.requested"
+	ActionTransferAccepted  = "transfer.accepted"
+	ActionLinkTransferred   = "url.transferred"
+)
@@ -0,0 +1,25 @@
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// Execer runs a statement. *sql.DB and *sql.Tx both satisfy it.
+type Execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Record writes an entry to audit_log. Pass the transaction that makes the
+// change being recorded, so the entry commits or rolls back with it.
+func Record(ex Execer, actorID int64, action, entityType string, entityID int64, details any) error {
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	_, err = ex.Exec(
+		"INSERT INTO audit_log (actor_id, action, entity_type, entity_id, details) VALUES ($1,$2,$3,$4,$5)",
+		actorID, action, entityType, entityID, string(raw),
+	)
+	return err
+}
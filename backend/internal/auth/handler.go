@@ -17,6 +17,7 @@ func NewHandler(service Service) *Handler {
 type registerRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Email    string `json:"email"`
 }
 
 type loginRequest struct {
@@ -38,7 +39,7 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Register(req.Username, req.Password); err != nil {
+	if err := h.service.Register(req.Username, req.Password, req.Email); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
@@ -3,6 +3,7 @@ package auth
 import (
 	"github.com/gin-gonic/gin"
 	"strings"
+	"url-shortener/internal/user"
 	"url-shortener/internal/utils"
 )
 
@@ -33,3 +34,27 @@ func Middleware(jwtService *utils.JWTService) gin.HandlerFunc {
 	}
 }
 
+
+// AdminMiddleware only lets users with users.is_admin through. It must run
+// after Middleware, which sets userId.
+func AdminMiddleware(userRepo user.Repository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		u, err := userRepo.GetByID(userID.(int64))
+		if err != nil {
+			c.AbortWithStatusJSON(500, gin.H{"error": "failed to load user"})
+			return
+		}
+		if u == nil || !u.IsAdmin {
+			c.AbortWithStatusJSON(403, gin.H{"error": "admin access required"})
+			return
+		}
+
+		c.Next()
+	}
+}
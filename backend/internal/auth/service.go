@@ -2,13 +2,15 @@ package auth
 
 import (
 	"errors"
+	"net/mail"
 	"os"
+	"strings"
 	"url-shortener/internal/user"
 	"url-shortener/internal/utils"
 )
 
 type Service interface {
-	Register(username, password string) error
+	Register(username, password, email string) error
 	Login(username, password string) (*LoginResponse, error)
 }
 
@@ -31,14 +33,24 @@ type LoginResponse struct {
 
 
 
-func (s *service) Register(username, password string) error {
+func (s *service) Register(username, password, email string) error {
 	exists, _ := s.userRepo.GetByUsername(username)
 	if exists != nil {
 		return errors.New("username already exists")
 	}
 
+	email = strings.TrimSpace(email)
+	if email != "" {
+		// Keep only the address: input like "Name <a@b.c>" parses too.
+		addr, err := mail.ParseAddress(email)
+		if err != nil {
+			return errors.New("invalid email address")
+		}
+		email = addr.Address
+	}
+
 	hashed := utils.HashPassword(password)
-	return s.userRepo.Create(username, hashed)
+	return s.userRepo.Create(username, hashed, email)
 }
 
 func (s *service) Login(username, password string) (*LoginResponse, error) {
@@ -0,0 +1,32 @@
+package blocklist
+
+import (
+	_ "embed"
+	"strings"
+)
+
+// defaultReserved are codes that collide with routes served on the short
+// link host or that we may want for our own pages later.
+var defaultReserved = []string{
+	"api", "l", "login", "logout", "register", "signup", "signin", "admin",
+	"dashboard", "settings", "account", "static", "assets", "public", "health",
+	"status", "help", "about", "terms", "privacy", "favicon.ico", "robots.txt",
+	".well-known", "www", "qr", "imports", "exports", "files",
+}
+
+//go:embed profanity.txt
+var profanityFile string
+
+// defaultProfanity reads the embedded word list, one word per line; blank
+// lines and # comments are skipped.
+func defaultProfanity() []string {
+	var words []string
+	for _, line := range strings.Split(profanityFile, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, strings.ToLower(line))
+	}
+	return words
+}
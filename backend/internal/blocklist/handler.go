@@ -0,0 +1,77 @@
+package blocklist
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+type addRequest struct {
+	Word string `json:"word"`
+	Kind string `json:"kind"`
+}
+
+// GET /api/admin/blocked-codes
+func (h *Handler) List(c *gin.Context) {
+	list, err := h.service.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if list == nil {
+		list = []*Entry{}
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// POST /api/admin/blocked-codes
+func (h *Handler) Add(c *gin.Context) {
+	var req addRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	e, err := h.service.Add(req.Word, req.Kind)
+	if err != nil {
+		if errors.Is(err, ErrEntryExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, e)
+}
+
+// DELETE /api/admin/blocked-codes/:id
+func (h *Handler) Remove(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.service.Remove(id); err != nil {
+		if errors.Is(err, ErrEntryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Blocked code removed"})
+}
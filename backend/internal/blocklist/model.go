@@ -0,0 +1,18 @@
+package blocklist
+
+import "time"
+
+const (
+	// KindReserved blocks a code exactly, e.g. one that collides with a route.
+	KindReserved = "reserved"
+	// KindProfanity blocks any code containing the word, including leetspeak
+	// spellings.
+	KindProfanity = "profanity"
+)
+
+type Entry struct {
+	ID        int64     `json:"id"`
+	Word      string    `json:"word"`
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,62 @@
+package blocklist
+
+import "database/sql"
+
+type Repository interface {
+	List() ([]*Entry, error)
+	Add(word, kind string) (*Entry, error)
+	Delete(id int64) (bool, error)
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) List() ([]*Entry, error) {
+	rows, err := r.db.Query("SELECT id, word, kind, created_at FROM blocked_codes ORDER BY kind, word")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Entry
+	for rows.Next() {
+		e := &Entry{}
+		if err := rows.Scan(&e.ID, &e.Word, &e.Kind, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// Add returns nil when the word is already listed with that kind.
+func (r *repository) Add(word, kind string) (*Entry, error) {
+	e := &Entry{Word: word, Kind: kind}
+	err := r.db.QueryRow(`
+		INSERT INTO blocked_codes (word, kind) VALUES ($1,$2)
+		ON CONFLICT (word, kind) DO NOTHING
+		RETURNING id, created_at`,
+		word, kind,
+	).Scan(&e.ID, &e.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *repository) Delete(id int64) (bool, error) {
+	res, err := r.db.Exec("DELETE FROM blocked_codes WHERE id=$1", id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
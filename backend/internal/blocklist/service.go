@@ -0,0 +1,140 @@
+package blocklist
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrReservedCode  = errors.New("code is reserved")
+	ErrOffensiveCode = errors.New("code contains a blocked word")
+	ErrEntryNotFound = errors.New("blocked code not found")
+	ErrEntryExists   = errors.New("word is already blocked")
+)
+
+type Service interface {
+	// Check returns ErrReservedCode or ErrOffensiveCode for codes that must
+	// not be used.
+	Check(code string) error
+	List() ([]*Entry, error)
+	Add(word, kind string) (*Entry, error)
+	Remove(id int64) error
+	Reload() error
+}
+
+// service keeps the built-in defaults plus the admin-managed entries in
+// memory; Check runs on every link creation and must not hit the database.
+type service struct {
+	repo  Repository
+	extra []string
+
+	mu        sync.RWMutex
+	reserved  map[string]bool
+	profanity []string
+}
+
+// NewService loads the list from the database. extraReserved adds codes from
+// configuration on top of the built-in defaults.
+func NewService(repo Repository, extraReserved []string) (Service, error) {
+	s := &service{repo: repo, extra: extraReserved}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *service) Reload() error {
+	entries, err := s.repo.List()
+	if err != nil {
+		return fmt.Errorf("failed to load blocked codes: %w", err)
+	}
+
+	reserved := make(map[string]bool)
+	for _, word := range append(defaultReserved, s.extra...) {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			reserved[word] = true
+		}
+	}
+	var profanity []string
+	for _, word := range defaultProfanity() {
+		profanity = append(profanity, normalize(word))
+	}
+	for _, e := range entries {
+		switch e.Kind {
+		case KindReserved:
+			reserved[strings.ToLower(e.Word)] = true
+		case KindProfanity:
+			profanity = append(profanity, normalize(e.Word))
+		}
+	}
+
+	s.mu.Lock()
+	s.reserved = reserved
+	s.profanity = profanity
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *service) Check(code string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.reserved[strings.ToLower(code)] {
+		return ErrReservedCode
+	}
+	normalized := normalize(code)
+	for _, word := range s.profanity {
+		if word != "" && strings.Contains(normalized, word) {
+			return ErrOffensiveCode
+		}
+	}
+	return nil
+}
+
+func (s *service) List() ([]*Entry, error) {
+	return s.repo.List()
+}
+
+func (s *service) Add(word, kind string) (*Entry, error) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" || len(word) > 64 {
+		return nil, errors.New("word must be between 1 and 64 characters")
+	}
+	if kind != KindReserved && kind != KindProfanity {
+		return nil, errors.New("kind must be reserved or profanity")
+	}
+
+	e, err := s.repo.Add(word, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add blocked code: %w", err)
+	}
+	if e == nil {
+		return nil, ErrEntryExists
+	}
+	return e, s.Reload()
+}
+
+func (s *service) Remove(id int64) error {
+	ok, err := s.repo.Delete(id)
+	if err != nil {
+		return fmt.Errorf("failed to remove blocked code: %w", err)
+	}
+	if !ok {
+		return ErrEntryNotFound
+	}
+	return s.Reload()
+}
+
+// leet maps common look-alike characters to the letters they stand for.
+var leet = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "9", "g",
+	"@", "a", "$", "s", "!", "i", "-", "", "_", "",
+)
+
+// normalize lowercases a code and undoes separators and leetspeak so that
+// "Sh1t" and "s-h-i-t" match "shit".
+func normalize(code string) string {
+	return leet.Replace(strings.ToLower(code))
+}
@@ -0,0 +1,116 @@
+package click
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Where a click came from. Links shared as text count as SourceLink; QR
+// codes and NFC tags encode a marker so scans and taps are counted apart.
+const (
+	SourceLink = "link"
+	SourceQR   = "qr"
+	SourceNFC  = "nfc"
+)
+
+// ValidSource reports whether s is a known click source.
+func ValidSource(s string) bool {
+	switch s {
+	case SourceLink, SourceQR, SourceNFC:
+		return true
+	}
+	return false
+}
+
+// ClickEvent is one visit to a short link, captured at redirect time.
+type ClickEvent struct {
+	URLID     int64
+	Source    string
+	Timestamp time.Time
+	Referrer  string
+	UserAgent string
+	// Device, browser and OS as read from UserAgent when the click is
+	// recorded.
+	Device         string
+	Browser        string
+	BrowserVersion string
+	OS             string
+	OSVersion      string
+	// IP is only used to compute IPHash and the location, and is never
+	// stored.
+	IP     string
+	IPHash string
+	// Country (ISO code), region and city looked up from IP.
+	Country        string
+	Region         string
+	City           string
+	AcceptLanguage string
+	QueryParams    map[string][]string
+	// Destination is the URL the visitor was redirected to.
+	Destination string
+}
+
+// Stored field limits, so crafted requests cannot bloat the table.
+const (
+	maxURLLength    = 2048
+	maxHeaderLength = 512
+	maxQueryParams  = 50
+	maxParamLength  = 512
+	// Fields read from the User-Agent and IP; custom rules and databases
+	// could produce any length.
+	maxDeviceLength  = 16
+	maxFamilyLength  = 64
+	maxVersionLength = 32
+	maxCountryLength = 2
+	maxPlaceLength   = 100
+)
+
+// normalize truncates oversized fields and fills in defaults.
+func (e *ClickEvent) normalize() {
+	if !ValidSource(e.Source) {
+		e.Source = SourceLink
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	// created_at has no time zone; clicks are stored as UTC wall-clock time.
+	e.Timestamp = e.Timestamp.UTC()
+	e.Referrer = truncate(e.Referrer, maxURLLength)
+	e.Destination = truncate(e.Destination, maxURLLength)
+	e.UserAgent = truncate(e.UserAgent, maxHeaderLength)
+	e.AcceptLanguage = truncate(e.AcceptLanguage, maxHeaderLength)
+	e.Device = truncate(e.Device, maxDeviceLength)
+	e.Browser = truncate(e.Browser, maxFamilyLength)
+	e.BrowserVersion = truncate(e.BrowserVersion, maxVersionLength)
+	e.OS = truncate(e.OS, maxFamilyLength)
+	e.Country = truncate(e.Country, maxCountryLength)
+	e.Region = truncate(e.Region, maxPlaceLength)
+	e.City = truncate(e.City, maxPlaceLength)
+	e.OSVersion = truncate(e.OSVersion, maxVersionLength)
+
+	params := make(map[string][]string, len(e.QueryParams))
+	for key, values := range e.QueryParams {
+		if len(params) == maxQueryParams {
+			break
+		}
+		key = truncate(key, maxParamLength)
+		for _, v := range values {
+			params[key] = append(params[key], truncate(v, maxParamLength))
+		}
+	}
+	e.QueryParams = params
+}
+
+// truncate limits s to n bytes without splitting a character. Headers are
+// untrusted, so invalid UTF-8, which Postgres would reject, is replaced first.
+func truncate(s string, n int) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -1,9 +1,12 @@
 package click
 
-import "database/sql"
+import (
+	"database/sql"
+	"encoding/json"
+)
 
 type Repository interface {
-	Add(urlID int64) error
+	Add(e *ClickEvent) error
 	Count(urlID int64) (int, error)
 }
 
@@ -15,8 +18,20 @@ func NewRepository(db *sql.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) Add(urlID int64) error {
-	_, err := r.db.Exec("INSERT INTO clicks (url_id) VALUES ($1)", urlID)
+func (r *repository) Add(e *ClickEvent) error {
+	params, err := json.Marshal(e.QueryParams)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO clicks (url_id, source, created_at, referrer, user_agent, ip_hash, accept_language,
+			query_params, destination, device_type, browser, browser_version, os, os_version,
+			country, region, city)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17)`,
+		e.URLID, e.Source, e.Timestamp, e.Referrer, e.UserAgent, e.IPHash, e.AcceptLanguage,
+		string(params), e.Destination, e.Device, e.Browser, e.BrowserVersion, e.OS, e.OSVersion,
+		e.Country, e.Region, e.City,
+	)
 	return err
 }
 
@@ -1,20 +1,64 @@
 package click
 
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"url-shortener/internal/geoip"
+	"url-shortener/internal/useragent"
+)
+
 type Service interface {
-	AddClick(urlID int64)
+	AddClick(e ClickEvent)
 	GetClicks(urlID int64) (int, error)
 }
 
 type service struct {
-	repo Repository
+	repo   Repository
+	salt   []byte
+	agents *useragent.Parser
+	geo    *geoip.DB
+}
+
+// NewService records clicks, hashing visitor IPs with salt so repeat
+// visitors can be recognised without storing their address, and reading
+// device details out of User-Agents with agents. When geo is not nil clicks
+// are also located by IP.
+func NewService(repo Repository, salt []byte, agents *useragent.Parser, geo *geoip.DB) Service {
+	return &service{repo: repo, salt: salt, agents: agents, geo: geo}
+}
+
+func (s *service) AddClick(e ClickEvent) {
+	a := s.agents.Parse(e.UserAgent)
+	e.Device, e.Browser, e.BrowserVersion, e.OS, e.OSVersion = a.Device, a.Browser, a.BrowserVersion, a.OS, a.OSVersion
+	if e.IP != "" {
+		e.IPHash = s.hashIP(e.IP)
+		s.locate(&e)
+		e.IP = ""
+	}
+	e.normalize()
+	if err := s.repo.Add(&e); err != nil {
+		log.Printf("❌ Failed to record click for link %d: %v", e.URLID, err)
+	}
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+func (s *service) locate(e *ClickEvent) {
+	if s.geo == nil {
+		return
+	}
+	loc, err := s.geo.Lookup(e.IP)
+	if err != nil {
+		log.Printf("⚠️ Failed to locate click for link %d: %v", e.URLID, err)
+		return
+	}
+	e.Country, e.Region, e.City = loc.Country, loc.Region, loc.City
 }
 
-func (s *service) AddClick(urlID int64) {
-	_ = s.repo.Add(urlID)
+func (s *service) hashIP(ip string) string {
+	mac := hmac.New(sha256.New, s.salt)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 func (s *service) GetClicks(urlID int64) (int, error) {
@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+type registerRequest struct {
+	Hostname string `json:"hostname"`
+}
+
+type verifyRequest struct {
+	Method string `json:"method"`
+}
+
+type domainResponse struct {
+	*Domain
+	TXTRecordName  string `json:"txt_record_name"`
+	TXTRecordValue string `json:"txt_record_value"`
+	WellKnownURL   string `json:"well_known_url"`
+}
+
+func toResponse(d *Domain) domainResponse {
+	return domainResponse{
+		Domain:         d,
+		TXTRecordName:  d.TXTRecordName(),
+		TXTRecordValue: d.TXTRecordValue(),
+		WellKnownURL:   d.WellKnownURL(),
+	}
+}
+
+// POST /api/domains
+func (h *Handler) Register(c *gin.Context) {
+	var req registerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	d, err := h.service.Register(userID.(int64), req.Hostname)
+	if err != nil {
+		if errors.Is(err, ErrDomainTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toResponse(d))
+}
+
+// GET /api/domains
+func (h *Handler) List(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	domains, err := h.service.List(userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := make([]domainResponse, 0, len(domains))
+	for _, d := range domains {
+		res = append(res, toResponse(d))
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// POST /api/domains/:id/verify
+func (h *Handler) Verify(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req verifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	d, err := h.service.Verify(userID.(int64), id, req.Method)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDomainNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrVerificationFailed):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrDomainTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, toResponse(d))
+}
+
+// DELETE /api/domains/:id
+func (h *Handler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := h.service.Delete(userID.(int64), id); err != nil {
+		if errors.Is(err, ErrDomainNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrDomainInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Domain deleted"})
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+type Domain struct {
+	ID                int64      `json:"id"`
+	UserID            int64      `json:"user_id"`
+	Hostname          string     `json:"hostname"`
+	VerificationToken string     `json:"verification_token"`
+	VerifiedAt        *time.Time `json:"verified_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+func (d *Domain) IsVerified() bool {
+	return d.VerifiedAt != nil
+}
+
+// TXTRecordName is the DNS name that must carry TXTRecordValue.
+func (d *Domain) TXTRecordName() string {
+	return "_shorty-verify." + d.Hostname
+}
+
+func (d *Domain) TXTRecordValue() string {
+	return "shorty-verify=" + d.VerificationToken
+}
+
+// WellKnownURL is where the HTTP method expects to find the token.
+func (d *Domain) WellKnownURL() string {
+	return "http://" + d.Hostname + "/.well-known/shorty-verify.txt"
+}
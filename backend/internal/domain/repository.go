@@ -0,0 +1,143 @@
+package domain
+
+import "database/sql"
+
+type Repository interface {
+	Create(userID int64, hostname, token string) (int64, error)
+	GetByID(id int64) (*Domain, error)
+	// GetVerifiedByHostname returns the verified domain for hostname.
+	GetVerifiedByHostname(hostname string) (*Domain, error)
+	GetByUserAndHostname(userID int64, hostname string) (*Domain, error)
+	ListByUser(userID int64) ([]*Domain, error)
+	// MarkVerified verifies the domain unless another claim on its hostname
+	// is already verified, reporting whether it did. Other claims on the
+	// hostname are then dropped.
+	MarkVerified(id int64) (bool, error)
+	CountURLs(id int64) (int, error)
+	Delete(id int64) error
+}
+
+const domainColumns = "id, user_id, hostname, verification_token, verified_at, created_at"
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func scanDomain(row interface{ Scan(dest ...any) error }) (*Domain, error) {
+	d := &Domain{}
+	if err := row.Scan(&d.ID, &d.UserID, &d.Hostname, &d.VerificationToken, &d.VerifiedAt, &d.CreatedAt); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *repository) Create(userID int64, hostname, token string) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(
+		"INSERT INTO domains (user_id, hostname, verification_token) VALUES ($1,$2,$3) RETURNING id",
+		userID, hostname, token,
+	).Scan(&id)
+	return id, err
+}
+
+func (r *repository) GetByID(id int64) (*Domain, error) {
+	d, err := scanDomain(r.db.QueryRow("SELECT "+domainColumns+" FROM domains WHERE id=$1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *repository) GetVerifiedByHostname(hostname string) (*Domain, error) {
+	d, err := scanDomain(r.db.QueryRow(
+		"SELECT "+domainColumns+" FROM domains WHERE hostname=$1 AND verified_at IS NOT NULL", hostname,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *repository) GetByUserAndHostname(userID int64, hostname string) (*Domain, error) {
+	d, err := scanDomain(r.db.QueryRow(
+		"SELECT "+domainColumns+" FROM domains WHERE user_id=$1 AND hostname=$2", userID, hostname,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *repository) ListByUser(userID int64) ([]*Domain, error) {
+	rows, err := r.db.Query("SELECT "+domainColumns+" FROM domains WHERE user_id=$1 ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var domains []*Domain
+	for rows.Next() {
+		d, err := scanDomain(rows)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, d)
+	}
+	return domains, rows.Err()
+}
+
+func (r *repository) MarkVerified(id int64) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE domains d SET verified_at=NOW()
+		WHERE d.id=$1 AND NOT EXISTS (
+			SELECT 1 FROM domains o WHERE o.hostname = d.hostname AND o.verified_at IS NOT NULL
+		)`,
+		id,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	// Unverified domains cannot carry links, so other claims go quietly.
+	_, err = tx.Exec(`
+		DELETE FROM domains
+		WHERE hostname = (SELECT hostname FROM domains WHERE id=$1) AND id<>$1 AND verified_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *repository) CountURLs(id int64) (int, error) {
+	var n int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM urls WHERE domain_id=$1", id).Scan(&n)
+	return n, err
+}
+
+func (r *repository) Delete(id int64) error {
+	_, err := r.db.Exec("DELETE FROM domains WHERE id=$1", id)
+	return err
+}
@@ -0,0 +1,197 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+var (
+	ErrDomainNotFound    = errors.New("domain not found")
+	ErrDomainTaken       = errors.New("domain is already registered")
+	ErrDomainNotVerified = errors.New("domain is not verified")
+	ErrDomainInUse       = errors.New("domain still has links; delete or move them first")
+)
+
+type Service interface {
+	Register(userID int64, hostname string) (*Domain, error)
+	Verify(userID, id int64, method string) (*Domain, error)
+	List(userID int64) ([]*Domain, error)
+	Delete(userID, id int64) error
+	// GetVerified returns the user's domain if it exists and is verified.
+	GetVerified(userID, id int64) (*Domain, error)
+	// ResolveHost returns the verified domain serving hostname, or nil.
+	ResolveHost(hostname string) (*Domain, error)
+}
+
+type service struct {
+	repo     Repository
+	verifier *Verifier
+}
+
+func NewService(repo Repository, verifier *Verifier) Service {
+	return &service{repo: repo, verifier: verifier}
+}
+
+var reservedHosts = []string{"localhost", "local", "internal"}
+
+func (s *service) Register(userID int64, hostname string) (*Domain, error) {
+	hostname = NormalizeHost(hostname)
+	if err := validateHostname(hostname); err != nil {
+		return nil, err
+	}
+
+	// Only verification proves control, so unverified claims do not block
+	// anyone: whoever verifies first keeps the hostname.
+	existing, err := s.repo.GetVerifiedByHostname(hostname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check domain: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrDomainTaken
+	}
+	own, err := s.repo.GetByUserAndHostname(userID, hostname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check domain: %w", err)
+	}
+	if own != nil {
+		return own, nil
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.Create(userID, hostname, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create domain: %w", err)
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *service) Verify(userID, id int64, method string) (*Domain, error) {
+	d, err := s.getOwned(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.IsVerified() {
+		return d, nil
+	}
+
+	if err := s.verifier.Verify(context.Background(), d, method); err != nil {
+		return nil, err
+	}
+	verified, err := s.repo.MarkVerified(d.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark domain verified: %w", err)
+	}
+	if !verified {
+		return nil, ErrDomainTaken
+	}
+	return s.repo.GetByID(d.ID)
+}
+
+func (s *service) List(userID int64) ([]*Domain, error) {
+	return s.repo.ListByUser(userID)
+}
+
+func (s *service) Delete(userID, id int64) error {
+	if _, err := s.getOwned(userID, id); err != nil {
+		return err
+	}
+	// Links keep their domain for life, so a domain in use cannot go.
+	n, err := s.repo.CountURLs(id)
+	if err != nil {
+		return fmt.Errorf("failed to check domain links: %w", err)
+	}
+	if n > 0 {
+		return ErrDomainInUse
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *service) GetVerified(userID, id int64) (*Domain, error) {
+	d, err := s.getOwned(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !d.IsVerified() {
+		return nil, ErrDomainNotVerified
+	}
+	return d, nil
+}
+
+func (s *service) ResolveHost(hostname string) (*Domain, error) {
+	d, err := s.repo.GetVerifiedByHostname(NormalizeHost(hostname))
+	if err != nil || d == nil || !d.IsVerified() {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *service) getOwned(userID, id int64) (*Domain, error) {
+	d, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load domain: %w", err)
+	}
+	if d == nil || d.UserID != userID {
+		return nil, ErrDomainNotFound
+	}
+	return d, nil
+}
+
+// NormalizeHost lowercases a Host header value and strips any port.
+func NormalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+func validateHostname(hostname string) error {
+	if len(hostname) > 253 {
+		return errors.New("hostname too long (max 253 characters)")
+	}
+	if net.ParseIP(hostname) != nil {
+		return errors.New("IP addresses cannot be used as custom domains")
+	}
+
+	labels := strings.Split(hostname, ".")
+	if len(labels) < 2 {
+		return errors.New("hostname must be a fully qualified domain name")
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 {
+			return errors.New("hostname contains an invalid label")
+		}
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return errors.New("hostname labels cannot start or end with hyphen")
+		}
+		for _, char := range label {
+			if !(char >= 'a' && char <= 'z') && !(char >= '0' && char <= '9') && char != '-' {
+				return fmt.Errorf("hostname contains invalid character: '%c'", char)
+			}
+		}
+	}
+
+	for _, reserved := range reservedHosts {
+		if hostname == reserved || strings.HasSuffix(hostname, "."+reserved) {
+			return fmt.Errorf("domain '%s' is not allowed", hostname)
+		}
+	}
+	return nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
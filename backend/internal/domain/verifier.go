@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"url-shortener/internal/utils"
+)
+
+const (
+	MethodDNS  = "dns"
+	MethodHTTP = "http"
+)
+
+// Resolver looks up TXT records. *net.Resolver satisfies it; tests can
+// supply a fake.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// HTTPClient fetches the well-known verification file. *http.Client
+// satisfies it.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type Verifier struct {
+	resolver Resolver
+	client   HTTPClient
+}
+
+func NewVerifier(resolver Resolver, client HTTPClient) *Verifier {
+	return &Verifier{resolver: resolver, client: client}
+}
+
+// NewHTTPClient returns the client for HTTP verification. Hostnames are user
+// input, so it only connects to public addresses and does not follow
+// redirects, which could lead anywhere.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: utils.PublicDialer(timeout).DialContext},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+var ErrVerificationFailed = errors.New("domain verification failed")
+
+func (v *Verifier) Verify(ctx context.Context, d *Domain, method string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	switch method {
+	case MethodDNS:
+		return v.verifyDNS(ctx, d)
+	case MethodHTTP:
+		return v.verifyHTTP(ctx, d)
+	}
+	return errors.New("method must be dns or http")
+}
+
+func (v *Verifier) verifyDNS(ctx context.Context, d *Domain) error {
+	records, err := v.resolver.LookupTXT(ctx, d.TXTRecordName())
+	if err != nil {
+		return fmt.Errorf("%w: TXT lookup for %s: %v", ErrVerificationFailed, d.TXTRecordName(), err)
+	}
+	for _, record := range records {
+		if strings.TrimSpace(record) == d.TXTRecordValue() {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: TXT record %s not found on %s", ErrVerificationFailed, d.TXTRecordValue(), d.TXTRecordName())
+}
+
+func (v *Verifier) verifyHTTP(ctx context.Context, d *Domain) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.WellKnownURL(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: fetching %s: %v", ErrVerificationFailed, d.WellKnownURL(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// The status itself is left out: it would tell callers about hosts
+		// they should not be able to probe.
+		return fmt.Errorf("%w: %s did not answer 200 OK", ErrVerificationFailed, d.WellKnownURL())
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return fmt.Errorf("%w: reading %s: %v", ErrVerificationFailed, d.WellKnownURL(), err)
+	}
+	if strings.TrimSpace(string(body)) != d.VerificationToken {
+		return fmt.Errorf("%w: token mismatch at %s", ErrVerificationFailed, d.WellKnownURL())
+	}
+	return nil
+}
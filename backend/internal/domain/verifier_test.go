@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url-shortener/internal/utils"
+)
+
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	records, ok := r[name]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return records, nil
+}
+
+type fakeClient func(req *http.Request) (*http.Response, error)
+
+func (f fakeClient) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// serve answers every request with status and body, recording the URL.
+func serve(status int, body string, fetched *string) fakeClient {
+	return func(req *http.Request) (*http.Response, error) {
+		*fetched = req.URL.String()
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}
+}
+
+func TestVerifyDNS(t *testing.T) {
+	d := &Domain{Hostname: "go.example.com", VerificationToken: "abc123"}
+
+	tests := []struct {
+		name     string
+		resolver fakeResolver
+		wantErr  bool
+	}{
+		{"matching record", fakeResolver{"_shorty-verify.go.example.com": {"shorty-verify=abc123"}}, false},
+		{"among other records", fakeResolver{"_shorty-verify.go.example.com": {"v=spf1 -all", " shorty-verify=abc123 "}}, false},
+		{"wrong token", fakeResolver{"_shorty-verify.go.example.com": {"shorty-verify=other"}}, true},
+		{"record on the bare host", fakeResolver{"go.example.com": {"shorty-verify=abc123"}}, true},
+		{"lookup fails", fakeResolver{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewVerifier(tt.resolver, nil).Verify(context.Background(), d, MethodDNS)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrVerificationFailed) {
+				t.Errorf("Verify = %v, want ErrVerificationFailed", err)
+			}
+		})
+	}
+}
+
+func TestVerifyHTTP(t *testing.T) {
+	d := &Domain{Hostname: "go.example.com", VerificationToken: "abc123"}
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{"token", http.StatusOK, "abc123", false},
+		{"token with newline", http.StatusOK, "abc123\n", false},
+		{"wrong token", http.StatusOK, "nope", true},
+		{"not found", http.StatusNotFound, "abc123", true},
+		{"token past the read limit", http.StatusOK, strings.Repeat(" ", 1024) + "abc123", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetched string
+			err := NewVerifier(nil, serve(tt.status, tt.body, &fetched)).Verify(context.Background(), d, MethodHTTP)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fetched != "http://go.example.com/.well-known/shorty-verify.txt" {
+				t.Errorf("fetched %q", fetched)
+			}
+		})
+	}
+}
+
+func TestVerifyRequestFails(t *testing.T) {
+	d := &Domain{Hostname: "go.example.com", VerificationToken: "abc123"}
+	client := fakeClient(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+	err := NewVerifier(nil, client).Verify(context.Background(), d, MethodHTTP)
+	if !errors.Is(err, ErrVerificationFailed) {
+		t.Errorf("Verify = %v, want ErrVerificationFailed", err)
+	}
+}
+
+func TestVerifyUnknownMethod(t *testing.T) {
+	d := &Domain{Hostname: "go.example.com", VerificationToken: "abc123"}
+	err := NewVerifier(fakeResolver{}, nil).Verify(context.Background(), d, "email")
+	if err == nil || errors.Is(err, ErrVerificationFailed) {
+		t.Errorf("Verify = %v, want a method error", err)
+	}
+}
+
+func TestHTTPClientStaysPublic(t *testing.T) {
+	var reached bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer srv.Close()
+
+	client := NewHTTPClient(time.Second)
+	if _, err := client.Get(srv.URL); !errors.Is(err, utils.ErrAddressNotAllowed) {
+		t.Errorf("Get(%s) = %v, want ErrAddressNotAllowed", srv.URL, err)
+	}
+	if reached {
+		t.Error("client reached a loopback server")
+	}
+
+	redirect := httptest.NewRequest(http.MethodGet, "http://169.254.169.254/", nil)
+	if err := client.CheckRedirect(redirect, nil); err != http.ErrUseLastResponse {
+		t.Errorf("CheckRedirect = %v, want redirects left unfollowed", err)
+	}
+}
@@ -0,0 +1,62 @@
+package export
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"url-shortener/internal/workspace"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GET /api/exports?format=csv|jsonl|zip&dataset=links|clicks&workspace_id=
+func (h *Handler) Download(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	in := Input{Format: c.Query("format"), Dataset: c.Query("dataset")}
+	if raw := c.Query("workspace_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace_id"})
+			return
+		}
+		in.WorkspaceID = &id
+	}
+
+	exp, err := h.service.Prepare(userID.(int64), in)
+	if err != nil {
+		switch {
+		case errors.Is(err, workspace.ErrWorkspaceNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, workspace.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Header("Content-Type", exp.ContentType)
+	c.Header("Content-Disposition", `attachment; filename="`+exp.Filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	// Headers are already sent, so a failure midway can only be logged; the
+	// client sees a truncated file.
+	if err := exp.Stream(c.Writer); err != nil {
+		log.Printf("❌ Export for user %d failed: %v", userID.(int64), err)
+	}
+}
@@ -0,0 +1,67 @@
+package export
+
+import (
+	"time"
+	"url-shortener/internal/url"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatZIP   = "zip"
+)
+
+const (
+	DatasetLinks  = "links"
+	DatasetClicks = "clicks"
+)
+
+// Scope selects a user's personal links, or every link in WorkspaceID.
+type Scope struct {
+	UserID      int64
+	WorkspaceID *int64
+}
+
+// Link is one exported link with its settings.
+type Link struct {
+	ID              int64      `json:"id"`
+	ShortCode       string     `json:"short_code"`
+	ShortURL        string     `json:"short_url"`
+	OriginalURL     string     `json:"original_url"`
+	Domain          string     `json:"domain,omitempty"`
+	WorkspaceID     *int64     `json:"workspace_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	Enabled         bool       `json:"enabled"`
+	ExpiredBehavior string     `json:"expired_behavior"`
+	FallbackURL     string     `json:"fallback_url,omitempty"`
+	ExpiredMessage  string     `json:"expired_message,omitempty"`
+	RedirectStatus  int        `json:"redirect_status"`
+	Tags            url.Tags   `json:"tags"`
+	QRURL           string     `json:"qr_url"`
+	Clicks          int        `json:"clicks"`
+}
+
+// Click is one raw click event.
+type Click struct {
+	ID             int64     `json:"id"`
+	URLID          int64     `json:"url_id"`
+	ShortCode      string    `json:"short_code"`
+	Source         string    `json:"source"`
+	CreatedAt      time.Time `json:"created_at"`
+	Referrer       string    `json:"referrer"`
+	UserAgent      string    `json:"user_agent"`
+	AcceptLanguage string    `json:"accept_language"`
+	Destination    string    `json:"destination"`
+	// QueryParams are the query parameters the short link was visited
+	// with.
+	QueryParams    map[string][]string `json:"query_params"`
+	Device         string              `json:"device"`
+	Browser        string              `json:"browser"`
+	BrowserVersion string              `json:"browser_version"`
+	OS             string              `json:"os"`
+	OSVersion      string              `json:"os_version"`
+	Country        string              `json:"country"`
+	Region         string              `json:"region"`
+	City           string              `json:"city"`
+}
@@ -0,0 +1,139 @@
+package export
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"url-shortener/internal/url"
+)
+
+// Repository streams rows to a callback a page at a time, so exports of large
+// accounts run in constant memory.
+type Repository interface {
+	EachLink(scope Scope, fn func(*Link) error) error
+	EachClick(scope Scope, fn func(*Click) error) error
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+const scopeFilter = "(($2::int IS NULL AND u.user_id = $1 AND u.workspace_id IS NULL) OR u.workspace_id = $2)"
+
+// pageSize is how many rows each query reads. A page is read in full and
+// its connection released before the rows are written out, so a slow
+// download never holds a database connection.
+const pageSize = 1000
+
+func (r *repository) EachLink(scope Scope, fn func(*Link) error) error {
+	afterID := int64(0)
+	for {
+		page, err := r.linkPage(scope, afterID)
+		if err != nil {
+			return err
+		}
+		for _, l := range page {
+			if err := fn(l); err != nil {
+				return err
+			}
+		}
+		if len(page) < pageSize {
+			return nil
+		}
+		afterID = page[len(page)-1].ID
+	}
+}
+
+func (r *repository) linkPage(scope Scope, afterID int64) ([]*Link, error) {
+	rows, err := r.db.Query(`
+		SELECT u.id, u.short_code, u.original_url, COALESCE(d.hostname, ''), u.workspace_id, u.created_at,
+			u.expires_at, u.enabled, u.expired_behavior, u.fallback_url, u.expired_message, u.redirect_status,
+			to_json(u.tags), u.qr_url, u.qr_status,
+			(SELECT COUNT(*) FROM clicks c WHERE c.url_id = u.id)
+		FROM urls u
+		LEFT JOIN domains d ON d.id = u.domain_id
+		WHERE `+scopeFilter+` AND u.id > $3
+		ORDER BY u.id
+		LIMIT $4`,
+		scope.UserID, scope.WorkspaceID, afterID, pageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := make([]*Link, 0, pageSize)
+	for rows.Next() {
+		l := &Link{}
+		var qrStatus string
+		if err := rows.Scan(
+			&l.ID, &l.ShortCode, &l.OriginalURL, &l.Domain, &l.WorkspaceID, &l.CreatedAt,
+			&l.ExpiresAt, &l.Enabled, &l.ExpiredBehavior, &l.FallbackURL, &l.ExpiredMessage, &l.RedirectStatus,
+			&l.Tags, &l.QRURL, &qrStatus, &l.Clicks,
+		); err != nil {
+			return nil, err
+		}
+		l.ShortURL = url.BuildShortURL(l.Domain, l.ShortCode)
+		// A restyled or failed image is served on demand, as in API
+		// responses.
+		l.QRURL = (&url.URL{Domain: l.Domain, ShortCode: l.ShortCode, QRURL: l.QRURL, QRStatus: qrStatus}).QRImageURL()
+		page = append(page, l)
+	}
+	return page, rows.Err()
+}
+
+func (r *repository) EachClick(scope Scope, fn func(*Click) error) error {
+	afterID := int64(0)
+	for {
+		page, err := r.clickPage(scope, afterID)
+		if err != nil {
+			return err
+		}
+		for _, c := range page {
+			if err := fn(c); err != nil {
+				return err
+			}
+		}
+		if len(page) < pageSize {
+			return nil
+		}
+		afterID = page[len(page)-1].ID
+	}
+}
+
+func (r *repository) clickPage(scope Scope, afterID int64) ([]*Click, error) {
+	rows, err := r.db.Query(`
+		SELECT c.id, c.url_id, u.short_code, c.source, c.created_at, c.referrer, c.user_agent, c.accept_language, c.destination,
+			c.query_params, c.device_type, c.browser, c.browser_version, c.os, c.os_version, c.country, c.region, c.city
+		FROM clicks c
+		JOIN urls u ON u.id = c.url_id
+		WHERE `+scopeFilter+` AND c.id > $3
+		ORDER BY c.id
+		LIMIT $4`,
+		scope.UserID, scope.WorkspaceID, afterID, pageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := make([]*Click, 0, pageSize)
+	for rows.Next() {
+		c := &Click{}
+		var params []byte
+		if err := rows.Scan(&c.ID, &c.URLID, &c.ShortCode, &c.Source, &c.CreatedAt,
+			&c.Referrer, &c.UserAgent, &c.AcceptLanguage, &c.Destination,
+			&params, &c.Device, &c.Browser, &c.BrowserVersion, &c.OS, &c.OSVersion, &c.Country, &c.Region, &c.City); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(params, &c.QueryParams); err != nil {
+			return nil, fmt.Errorf("failed to decode query params of click %d: %w", c.ID, err)
+		}
+		page = append(page, c)
+	}
+	return page, rows.Err()
+}
@@ -0,0 +1,93 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"url-shortener/internal/workspace"
+)
+
+var ErrInvalidFormat = errors.New("format must be one of csv, jsonl, zip")
+
+type Input struct {
+	Format      string
+	Dataset     string
+	WorkspaceID *int64
+}
+
+// Export is an authorized, ready-to-stream download.
+type Export struct {
+	Filename    string
+	ContentType string
+	write       func(io.Writer) error
+}
+
+// Stream writes the export to w. Rows are read from the database a page at a
+// time as they are written, so memory use does not grow with the account
+// size.
+func (e *Export) Stream(w io.Writer) error {
+	return e.write(w)
+}
+
+type Service interface {
+	Prepare(userID int64, in Input) (*Export, error)
+}
+
+type service struct {
+	repo             Repository
+	workspaceService workspace.Service
+}
+
+func NewService(repo Repository, workspaceService workspace.Service) Service {
+	return &service{repo: repo, workspaceService: workspaceService}
+}
+
+// Prepare checks access and picks the writer for the requested format. The
+// CSV format holds one dataset (links by default); JSON Lines and ZIP
+// include both links and clicks.
+func (s *service) Prepare(userID int64, in Input) (*Export, error) {
+	if in.WorkspaceID != nil {
+		if _, err := s.workspaceService.Authorize(userID, *in.WorkspaceID, workspace.PermView); err != nil {
+			return nil, err
+		}
+	}
+	scope := Scope{UserID: userID, WorkspaceID: in.WorkspaceID}
+
+	name := fmt.Sprintf("shorty-export-%s", time.Now().UTC().Format("20060102-150405"))
+	if in.WorkspaceID != nil {
+		name = fmt.Sprintf("shorty-workspace-%d-export-%s", *in.WorkspaceID, time.Now().UTC().Format("20060102-150405"))
+	}
+
+	switch in.Format {
+	case FormatCSV, "":
+		switch in.Dataset {
+		case DatasetLinks, "":
+			return &Export{
+				Filename:    name + "-links.csv",
+				ContentType: "text/csv; charset=utf-8",
+				write:       func(w io.Writer) error { return s.writeLinksCSV(scope, w) },
+			}, nil
+		case DatasetClicks:
+			return &Export{
+				Filename:    name + "-clicks.csv",
+				ContentType: "text/csv; charset=utf-8",
+				write:       func(w io.Writer) error { return s.writeClicksCSV(scope, w) },
+			}, nil
+		}
+		return nil, errors.New("dataset must be links or clicks")
+	case FormatJSONL:
+		return &Export{
+			Filename:    name + ".jsonl",
+			ContentType: "application/x-ndjson",
+			write:       func(w io.Writer) error { return s.writeJSONL(scope, w) },
+		}, nil
+	case FormatZIP:
+		return &Export{
+			Filename:    name + ".zip",
+			ContentType: "application/zip",
+			write:       func(w io.Writer) error { return s.writeZIP(scope, w) },
+		}, nil
+	}
+	return nil, ErrInvalidFormat
+}
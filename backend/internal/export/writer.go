@@ -0,0 +1,188 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// flushEvery bounds how many CSV rows are buffered before being pushed to the
+// client.
+const flushEvery = 500
+
+var linkHeader = []string{
+	"id", "short_code", "short_url", "original_url", "domain", "workspace_id", "created_at",
+	"expires_at", "enabled", "expired_behavior", "fallback_url", "expired_message",
+	"redirect_status", "tags", "qr_url", "clicks",
+}
+
+func linkRow(l *Link) []string {
+	return []string{
+		strconv.FormatInt(l.ID, 10),
+		l.ShortCode,
+		l.ShortURL,
+		l.OriginalURL,
+		l.Domain,
+		formatID(l.WorkspaceID),
+		l.CreatedAt.UTC().Format(time.RFC3339),
+		formatTime(l.ExpiresAt),
+		strconv.FormatBool(l.Enabled),
+		l.ExpiredBehavior,
+		l.FallbackURL,
+		l.ExpiredMessage,
+		strconv.Itoa(l.RedirectStatus),
+		strings.Join(l.Tags, ";"),
+		l.QRURL,
+		strconv.Itoa(l.Clicks),
+	}
+}
+
+var clickHeader = []string{
+	"id", "url_id", "short_code", "source", "created_at",
+	"referrer", "user_agent", "accept_language", "destination", "query_params",
+	"device", "browser", "browser_version", "os", "os_version", "country", "region", "city",
+}
+
+func clickRow(c *Click) []string {
+	return []string{
+		strconv.FormatInt(c.ID, 10),
+		strconv.FormatInt(c.URLID, 10),
+		c.ShortCode,
+		c.Source,
+		c.CreatedAt.UTC().Format(time.RFC3339),
+		c.Referrer,
+		c.UserAgent,
+		c.AcceptLanguage,
+		c.Destination,
+		neturl.Values(c.QueryParams).Encode(),
+		c.Device,
+		c.Browser,
+		c.BrowserVersion,
+		c.OS,
+		c.OSVersion,
+		c.Country,
+		c.Region,
+		c.City,
+	}
+}
+
+func formatID(id *int64) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatInt(*id, 10)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// flusher is implemented by http.ResponseWriter; pushing partial output keeps
+// long exports from looking stalled.
+type flusher interface {
+	Flush()
+}
+
+func flush(w io.Writer) {
+	if f, ok := w.(flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *service) writeLinksCSV(scope Scope, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(linkHeader); err != nil {
+		return err
+	}
+	n := 0
+	err := s.repo.EachLink(scope, func(l *Link) error {
+		if err := cw.Write(linkRow(l)); err != nil {
+			return err
+		}
+		if n++; n%flushEvery == 0 {
+			cw.Flush()
+			flush(w)
+		}
+		return cw.Error()
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *service) writeClicksCSV(scope Scope, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(clickHeader); err != nil {
+		return err
+	}
+	n := 0
+	err := s.repo.EachClick(scope, func(c *Click) error {
+		if err := cw.Write(clickRow(c)); err != nil {
+			return err
+		}
+		if n++; n%flushEvery == 0 {
+			cw.Flush()
+			flush(w)
+		}
+		return cw.Error()
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSONL emits every link followed by every click, one object per line,
+// tagged with a "type" field.
+func (s *service) writeJSONL(scope Scope, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	err := s.repo.EachLink(scope, func(l *Link) error {
+		return enc.Encode(struct {
+			Type string `json:"type"`
+			*Link
+		}{"link", l})
+	})
+	if err != nil {
+		return err
+	}
+	return s.repo.EachClick(scope, func(c *Click) error {
+		return enc.Encode(struct {
+			Type string `json:"type"`
+			*Click
+		}{"click", c})
+	})
+}
+
+// writeZIP bundles links.csv and clicks.csv. zip.Writer streams each entry,
+// so nothing is staged on disk.
+func (s *service) writeZIP(scope Scope, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	entries := []struct {
+		name  string
+		write func(Scope, io.Writer) error
+	}{
+		{"links.csv", s.writeLinksCSV},
+		{"clicks.csv", s.writeClicksCSV},
+	}
+	for _, e := range entries {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		if err := e.write(scope, f); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
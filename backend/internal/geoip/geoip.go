@@ -0,0 +1,136 @@
+// Package geoip finds where an IP address is from in a local MaxMind-format
+// (.mmdb) database, such as GeoLite2 City, without calling any service. The
+// file is watched and swapped in when it is replaced, so scheduled database
+// updates need no restart.
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Location is where an address is from. Country is an ISO 3166-1 code;
+// Region and City are English names. Fields the database does not know
+// are empty.
+type Location struct {
+	Country string
+	Region  string
+	City    string
+}
+
+type DB struct {
+	path   string
+	reader atomic.Pointer[reader]
+	// seen is the file version last loaded or tried, so a broken file is
+	// reported once rather than on every check.
+	seen fileVersion
+}
+
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+// Open loads the database at path.
+func Open(path string) (*DB, error) {
+	db := &DB{path: path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.load(info); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *DB) load(info os.FileInfo) error {
+	db.seen = fileVersion{modTime: info.ModTime(), size: info.Size()}
+	buf, err := os.ReadFile(db.path)
+	if err != nil {
+		return err
+	}
+	r, err := newReader(buf)
+	if err != nil {
+		return fmt.Errorf("%s: %w", db.path, err)
+	}
+	db.reader.Store(r)
+	return nil
+}
+
+// Run reloads the database whenever the file changes, checking every
+// interval, until ctx is done. Lookups keep using the old data until the
+// new file has loaded, and a file that fails to load is skipped.
+func (db *DB) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(db.path)
+		if err != nil {
+			// Mid-replace, or removed; keep what is loaded.
+			continue
+		}
+		if (fileVersion{modTime: info.ModTime(), size: info.Size()}) == db.seen {
+			continue
+		}
+		if err := db.load(info); err != nil {
+			log.Printf("⚠️ Failed to reload GeoIP database, keeping the previous one: %v", err)
+			continue
+		}
+		log.Printf("✅ Reloaded GeoIP database %s", db.path)
+	}
+}
+
+// Lookup returns where ip is from. Unknown and private addresses give an
+// empty Location.
+func (db *DB) Lookup(ip string) (Location, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return Location{}, nil
+	}
+	v, err := db.reader.Load().lookup(addr)
+	if err != nil {
+		return Location{}, err
+	}
+	rec, _ := v.(map[string]any)
+	if rec == nil {
+		return Location{}, nil
+	}
+
+	var loc Location
+	country := field(rec, "country")
+	if country == nil {
+		country = field(rec, "registered_country")
+	}
+	loc.Country, _ = country["iso_code"].(string)
+	if subdivisions, ok := rec["subdivisions"].([]any); ok && len(subdivisions) > 0 {
+		if region, ok := subdivisions[0].(map[string]any); ok {
+			loc.Region = name(region)
+		}
+	}
+	loc.City = name(field(rec, "city"))
+	return loc, nil
+}
+
+func field(m map[string]any, key string) map[string]any {
+	v, _ := m[key].(map[string]any)
+	return v
+}
+
+// name reads the English name of a place.
+func name(place map[string]any) string {
+	s, _ := field(place, "names")["en"].(string)
+	return s
+}
@@ -0,0 +1,429 @@
+package geoip
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// The tests build databases in memory with the writer below rather than
+// shipping binary fixtures.
+
+// ptr is a data section pointer to an offset.
+type ptr uint
+
+// encode writes v in the MaxMind DB data format.
+func encode(v any) []byte {
+	var b bytes.Buffer
+	switch v := v.(type) {
+	case ptr:
+		b.Write([]byte{typePointer<<5 | byte(v>>8&0x7), byte(v)})
+	case string:
+		b.Write(header(typeString, len(v)))
+		b.WriteString(v)
+	case float64:
+		b.Write(header(typeDouble, 8))
+		binary.Write(&b, binary.BigEndian, math.Float64bits(v))
+	case float32:
+		b.Write(header(typeFloat, 4))
+		binary.Write(&b, binary.BigEndian, math.Float32bits(v))
+	case uint16:
+		b.Write(header(typeUint16, 2))
+		binary.Write(&b, binary.BigEndian, v)
+	case uint32:
+		b.Write(header(typeUint32, 4))
+		binary.Write(&b, binary.BigEndian, v)
+	case uint64:
+		b.Write(header(typeUint64, 8))
+		binary.Write(&b, binary.BigEndian, v)
+	case int32:
+		b.Write(header(typeInt32, 4))
+		binary.Write(&b, binary.BigEndian, v)
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+		b.Write(header(typeBool, size))
+	case []any:
+		b.Write(header(typeArray, len(v)))
+		for _, e := range v {
+			b.Write(encode(e))
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.Write(header(typeMap, len(v)))
+		for _, k := range keys {
+			b.Write(encode(k))
+			b.Write(encode(v[k]))
+		}
+	default:
+		panic(fmt.Sprintf("cannot encode %T", v))
+	}
+	return b.Bytes()
+}
+
+// header is a control byte, extended type and size, for sizes below 285.
+func header(typ, size int) []byte {
+	var ctrl []byte
+	sizeBits, extra := size, []byte(nil)
+	if size >= 29 {
+		sizeBits, extra = 29, []byte{byte(size - 29)}
+	}
+	if typ > 7 {
+		ctrl = []byte{byte(sizeBits), byte(typ - 7)}
+	} else {
+		ctrl = []byte{byte(typ<<5 | sizeBits)}
+	}
+	return append(ctrl, extra...)
+}
+
+type network struct {
+	cidr string
+	data int // index into the records
+}
+
+// build writes a database mapping each network to one of the records.
+// records may hold ptr values relative to the start of the data section.
+func build(recordSize, ipVersion int, networks []network, records [][]byte) []byte {
+	type node struct {
+		child [2]*node
+		data  int
+	}
+	root := &node{data: -1}
+	for _, n := range networks {
+		_, ipnet, err := net.ParseCIDR(n.cidr)
+		if err != nil {
+			panic(err)
+		}
+		ip := ipnet.IP
+		ones, _ := ipnet.Mask.Size()
+		if ip4 := ip.To4(); ip4 != nil && ipVersion == 6 {
+			// IPv6 trees hold IPv4 networks under ::/96.
+			ip, ones = append(make(net.IP, 12), ip4...), ones+96
+		}
+		cur := root
+		for i := 0; i < ones; i++ {
+			bit := ip[i>>3] >> (7 - uint(i&7)) & 1
+			if cur.child[bit] == nil {
+				cur.child[bit] = &node{data: -1}
+			}
+			cur = cur.child[bit]
+		}
+		cur.data = n.data
+	}
+
+	var data bytes.Buffer
+	offsets := make([]int, len(records))
+	for i, r := range records {
+		offsets[i] = data.Len()
+		data.Write(r)
+	}
+
+	// Number the inner nodes breadth first; leaves become data records.
+	var nodes []*node
+	index := map[*node]int{}
+	for queue := []*node{root}; len(queue) > 0; queue = queue[1:] {
+		n := queue[0]
+		index[n] = len(nodes)
+		nodes = append(nodes, n)
+		for _, c := range n.child {
+			if c != nil && c.data < 0 {
+				queue = append(queue, c)
+			}
+		}
+	}
+	count := len(nodes)
+	value := func(c *node) uint32 {
+		switch {
+		case c == nil:
+			return uint32(count)
+		case c.data >= 0:
+			return uint32(count + dataSectionSeparator + offsets[c.data])
+		}
+		return uint32(index[c])
+	}
+
+	var tree bytes.Buffer
+	for _, n := range nodes {
+		l, r := value(n.child[0]), value(n.child[1])
+		switch recordSize {
+		case 24:
+			tree.Write([]byte{byte(l >> 16), byte(l >> 8), byte(l), byte(r >> 16), byte(r >> 8), byte(r)})
+		case 28:
+			tree.Write([]byte{byte(l >> 16), byte(l >> 8), byte(l), byte(l>>20&0xF0 | r>>24&0x0F), byte(r >> 16), byte(r >> 8), byte(r)})
+		case 32:
+			binary.Write(&tree, binary.BigEndian, [2]uint32{l, r})
+		}
+	}
+
+	var out bytes.Buffer
+	out.Write(tree.Bytes())
+	out.Write(make([]byte, dataSectionSeparator))
+	out.Write(data.Bytes())
+	out.Write(metadataMarker)
+	out.Write(encode(map[string]any{
+		"node_count":    uint32(count),
+		"record_size":   uint16(recordSize),
+		"ip_version":    uint16(ipVersion),
+		"database_type": "Test-City",
+	}))
+	return out.Bytes()
+}
+
+func place(name string) map[string]any {
+	return map[string]any{"names": map[string]any{"en": name, "de": name + "-de"}}
+}
+
+// testDB maps a few networks to records covering country, region and city,
+// the registered_country fallback, and a pointer to a shared value.
+func testDB(recordSize, ipVersion int) []byte {
+	london := encode(map[string]any{
+		"city":         place("London"),
+		"country":      map[string]any{"iso_code": "GB"},
+		"subdivisions": []any{place("England"), place("Greater London")},
+		"location":     map[string]any{"latitude": 51.5142, "longitude": -0.0931},
+	})
+	registered := encode(map[string]any{
+		"registered_country": map[string]any{"iso_code": "US"},
+	})
+	shared := encode(map[string]any{"iso_code": "SE"})
+	viaPointer := encode(map[string]any{
+		"country": ptr(len(london) + len(registered)),
+		"city":    place("Linköping"),
+	})
+	networks := []network{
+		{"81.2.69.0/24", 0},
+		{"8.8.8.0/24", 1},
+		{"89.160.20.112/28", 3},
+	}
+	if ipVersion == 6 {
+		networks = append(networks, network{"2a02:ff40::/32", 0})
+	}
+	return build(recordSize, ipVersion, networks, [][]byte{london, registered, shared, viaPointer})
+}
+
+func openBytes(t *testing.T, buf []byte) *DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		ip   string
+		ipv6 bool
+		want Location
+	}{
+		{"81.2.69.142", false, Location{"GB", "England", "London"}},
+		{"81.2.69.0", false, Location{"GB", "England", "London"}},
+		{"81.2.70.1", false, Location{}},
+		{"8.8.8.8", false, Location{Country: "US"}},
+		{"89.160.20.113", false, Location{"SE", "", "Linköping"}},
+		{"89.160.20.128", false, Location{}},
+		{"10.0.0.1", false, Location{}},
+		{"::ffff:81.2.69.142", false, Location{"GB", "England", "London"}},
+		{"2a02:ff40::1", true, Location{"GB", "England", "London"}},
+		{"2a02:ff41::1", true, Location{}},
+		{"not an ip", false, Location{}},
+		{"", false, Location{}},
+	}
+	for _, recordSize := range []int{24, 28, 32} {
+		for _, ipVersion := range []int{4, 6} {
+			db := openBytes(t, testDB(recordSize, ipVersion))
+			for _, tt := range tests {
+				want := tt.want
+				if tt.ipv6 && ipVersion == 4 {
+					want = Location{}
+				}
+				got, err := db.Lookup(tt.ip)
+				if err != nil {
+					t.Errorf("record size %d, IPv%d: Lookup(%q) error: %v", recordSize, ipVersion, tt.ip, err)
+					continue
+				}
+				if got != want {
+					t.Errorf("record size %d, IPv%d: Lookup(%q) = %+v, want %+v", recordSize, ipVersion, tt.ip, got, want)
+				}
+			}
+		}
+	}
+}
+
+// 28-bit records split their top nibble across the middle byte; node and
+// data values above 2^24 exercise it.
+func TestRecord28HighBits(t *testing.T) {
+	r := &reader{
+		buf:        []byte{0x12, 0x34, 0x56, 0xAB, 0x78, 0x9A, 0xBC},
+		recordSize: 28,
+	}
+	if got, want := r.record(0, 0), uint(0xA123456); got != want {
+		t.Errorf("left = %#x, want %#x", got, want)
+	}
+	if got, want := r.record(0, 1), uint(0xB789ABC); got != want {
+		t.Errorf("right = %#x, want %#x", got, want)
+	}
+}
+
+func TestNewReaderRejects(t *testing.T) {
+	valid := testDB(24, 6)
+	meta := func(m map[string]any) []byte {
+		i := bytes.LastIndex(valid, metadataMarker)
+		buf := append([]byte(nil), valid[:i]...)
+		return append(append(buf, metadataMarker...), encode(m)...)
+	}
+
+	tests := []struct {
+		name string
+		buf  []byte
+	}{
+		{"empty", nil},
+		{"no metadata", valid[:bytes.LastIndex(valid, metadataMarker)]},
+		{"metadata not a map", append(append([]byte(nil), metadataMarker...), encode("x")...)},
+		{"record size", meta(map[string]any{"node_count": uint32(1), "record_size": uint16(20), "ip_version": uint16(6)})},
+		{"ip version", meta(map[string]any{"node_count": uint32(1), "record_size": uint16(24), "ip_version": uint16(5)})},
+		{"tree past the file", meta(map[string]any{"node_count": uint32(1 << 20), "record_size": uint16(24), "ip_version": uint16(6)})},
+	}
+	for _, tt := range tests {
+		if _, err := newReader(tt.buf); !errors.Is(err, errInvalidDatabase) {
+			t.Errorf("%s: newReader error = %v, want errInvalidDatabase", tt.name, err)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	long := string(bytes.Repeat([]byte("x"), 200))
+	tests := []struct {
+		name string
+		buf  []byte
+		want any
+	}{
+		{"string", encode("hello"), "hello"},
+		{"long string", encode(long), long},
+		{"empty string", encode(""), ""},
+		{"double", encode(-0.0931), -0.0931},
+		{"float", encode(float32(1.5)), 1.5},
+		{"uint16", encode(uint16(443)), uint64(443)},
+		{"uint32", encode(uint32(1 << 31)), uint64(1 << 31)},
+		{"uint64", encode(uint64(1 << 40)), uint64(1 << 40)},
+		{"negative int32", encode(int32(-5)), int64(-5)},
+		{"short uint16", []byte{typeUint16<<5 | 1, 0x7F}, uint64(0x7F)},
+		{"zero-length uint32", []byte{typeUint32<<5 | 0}, uint64(0)},
+		{"true", encode(true), true},
+		{"false", encode(false), false},
+		{"array", encode([]any{"a", uint16(1)}), []any{"a", uint64(1)}},
+	}
+	for _, tt := range tests {
+		got, next, err := decoder{buf: tt.buf}.decode(0, 0)
+		if err != nil {
+			t.Errorf("%s: decode error: %v", tt.name, err)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) || fmt.Sprintf("%T", got) != fmt.Sprintf("%T", tt.want) {
+			t.Errorf("%s: decode = %#v, want %#v", tt.name, got, tt.want)
+		}
+		if next != uint(len(tt.buf)) {
+			t.Errorf("%s: next offset = %d, want %d", tt.name, next, len(tt.buf))
+		}
+	}
+}
+
+func TestDecodePointer(t *testing.T) {
+	// A map whose value points back at an earlier string; decoding
+	// continues after the pointer, not after its target.
+	target := encode("shared")
+	buf := append(append([]byte(nil), target...), encode(map[string]any{"a": ptr(0), "b": "x"})...)
+	got, next, err := decoder{buf: buf}.decode(uint(len(target)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != "map[a:shared b:x]" || next != uint(len(buf)) {
+		t.Errorf("decode = %v, %d", got, next)
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	tests := []struct {
+		name string
+		buf  []byte
+	}{
+		{"empty", nil},
+		{"truncated string", encode("hello")[:3]},
+		{"truncated size", []byte{typeString<<5 | 30, 0x01}},
+		{"truncated extended type", []byte{0x02}},
+		{"bad double size", []byte{typeDouble<<5 | 4, 0, 0, 0, 0}},
+		{"oversized uint", append(header(typeUint64, 9), make([]byte, 9)...)},
+		{"map key not a string", append(header(typeMap, 1), append(encode(uint16(1)), encode("v")...)...)},
+		{"pointer past the end", []byte{typePointer<<5 | 0x07, 0xFF}},
+		{"pointer loop", encode(ptr(0))},
+		{"unsupported type", []byte{0x00, typeContainer - 7}},
+	}
+	for _, tt := range tests {
+		if v, _, err := (decoder{buf: tt.buf}).decode(0, 0); err == nil {
+			t.Errorf("%s: decode = %v, want error", tt.name, v)
+		}
+	}
+}
+
+func TestRunReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, testDB(24, 6), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go db.Run(ctx, 5*time.Millisecond)
+
+	waitFor := func(ip string, want Location) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			got, _ := db.Lookup(ip)
+			if got == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Lookup(%q) = %+v, want %+v", ip, got, want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// A broken file is skipped and the loaded data kept.
+	if err := os.WriteFile(path, []byte("not a database"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	waitFor("81.2.69.142", Location{"GB", "England", "London"})
+
+	replaced := build(32, 6, []network{{"81.2.69.0/24", 0}}, [][]byte{
+		encode(map[string]any{"country": map[string]any{"iso_code": "FR"}}),
+	})
+	if err := os.WriteFile(path, replaced, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("81.2.69.142", Location{Country: "FR"})
+}
@@ -0,0 +1,52 @@
+package importer
+
+import "time"
+
+const (
+	FormatBitly     = "bitly_csv"
+	FormatCSV       = "csv"
+	FormatBookmarks = "bookmarks_html"
+)
+
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Record is one link read from an import file.
+type Record struct {
+	Row         int
+	OriginalURL string
+	Alias       string
+	Tags        []string
+	CreatedAt   *time.Time
+}
+
+// Conflict explains why a row was not imported exactly as given.
+type Conflict struct {
+	Row         int    `json:"row"`
+	OriginalURL string `json:"original_url"`
+	Alias       string `json:"alias,omitempty"`
+	Reason      string `json:"reason"`
+	ShortCode   string `json:"short_code,omitempty"`
+}
+
+type Job struct {
+	ID          int64      `json:"id"`
+	UserID      int64      `json:"user_id"`
+	WorkspaceID *int64     `json:"workspace_id,omitempty"`
+	DomainID    *int64     `json:"domain_id,omitempty"`
+	Format      string     `json:"format"`
+	Status      string     `json:"status"`
+	Total       int        `json:"total"`
+	Processed   int        `json:"processed"`
+	Created     int        `json:"created"`
+	Duplicates  int        `json:"duplicates"`
+	Failed      int        `json:"failed"`
+	Conflicts   []Conflict `json:"conflicts"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
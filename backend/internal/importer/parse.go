@@ -0,0 +1,277 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Parse reads every record from an export file in the given format.
+func Parse(format string, r io.Reader) ([]Record, error) {
+	switch format {
+	case FormatBitly:
+		return parseCSV(r, bitlyColumns, bitlyAlias)
+	case FormatCSV:
+		return parseCSV(r, genericColumns, genericAlias)
+	case FormatBookmarks:
+		return parseBookmarks(r)
+	}
+	return nil, errors.New("format must be one of bitly_csv, csv, bookmarks_html")
+}
+
+// columns lists accepted header names per field, after normalizeHeader.
+type columns struct {
+	original []string
+	alias    []string
+	created  []string
+	tags     []string
+}
+
+var genericColumns = columns{
+	original: []string{"original", "original_url", "url", "long_url", "destination"},
+	alias:    []string{"alias", "short_code", "slug", "code"},
+	created:  []string{"created", "created_at", "date_created", "date"},
+	tags:     []string{"tags", "tag"},
+}
+
+// Bitly exports the short link itself plus any custom back-halves.
+var bitlyColumns = columns{
+	original: []string{"long_url", "destination_url", "original_url"},
+	alias:    []string{"custom_bitlinks", "custom_bitlink", "bitlink", "link", "short_url"},
+	created:  []string{"created_at", "date_created", "created"},
+	tags:     []string{"tags"},
+}
+
+func genericAlias(value string) string {
+	return strings.TrimSpace(value)
+}
+
+// bitlyAlias turns "bit.ly/abc" or "https://bit.ly/abc, bit.ly/def" into
+// "abc", the back-half we can try to keep.
+func bitlyAlias(value string) string {
+	parts := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '|'
+	})
+	if len(parts) == 0 {
+		return ""
+	}
+	first := parts[0]
+	if !strings.Contains(first, "://") {
+		first = "https://" + first
+	}
+	u, err := neturl.Parse(first)
+	if err != nil {
+		return ""
+	}
+	return strings.Trim(u.Path, "/")
+}
+
+func parseCSV(r io.Reader, cols columns, alias func(string) string) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[normalizeHeader(name)] = i
+	}
+
+	find := func(names []string) int {
+		for _, name := range names {
+			if i, ok := index[name]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+	originalCol := find(cols.original)
+	if originalCol < 0 {
+		return nil, fmt.Errorf("CSV must have one of the columns: %s", strings.Join(cols.original, ", "))
+	}
+	var aliasCols []int
+	for _, name := range cols.alias {
+		if i, ok := index[name]; ok {
+			aliasCols = append(aliasCols, i)
+		}
+	}
+	createdCol := find(cols.created)
+	tagsCol := find(cols.tags)
+
+	field := func(row []string, col int) string {
+		if col < 0 || col >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[col])
+	}
+
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		// Quoted fields may span lines, so rows are numbered by the line
+		// they start on.
+		line, _ := reader.FieldPos(0)
+
+		original := field(row, originalCol)
+		if original == "" {
+			continue
+		}
+		// Alias columns are listed by preference, so Bitly's custom
+		// back-half wins over the generated one.
+		rec := Record{
+			Row:         line,
+			OriginalURL: original,
+			Tags:        splitTags(field(row, tagsCol)),
+			CreatedAt:   parseTime(field(row, createdCol)),
+		}
+		for _, col := range aliasCols {
+			if rec.Alias = alias(field(row, col)); rec.Alias != "" {
+				break
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// parseBookmarks reads the Netscape bookmark format exported by every major
+// browser. Folder names become tags alongside any TAGS attribute.
+func parseBookmarks(r io.Reader) ([]Record, error) {
+	z := html.NewTokenizer(r)
+
+	var folders []string
+	pendingFolder := ""
+	inFolderTitle := false
+	var current *Record
+	var records []Record
+	count := 0
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return records, nil
+			}
+			return nil, fmt.Errorf("failed to parse bookmarks: %w", z.Err())
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "h3":
+				inFolderTitle = true
+				pendingFolder = ""
+			case "dl":
+				folders = append(folders, pendingFolder)
+				pendingFolder = ""
+			case "a":
+				count++
+				rec := Record{Row: count}
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = z.TagAttr()
+					switch strings.ToLower(string(key)) {
+					case "href":
+						rec.OriginalURL = strings.TrimSpace(string(val))
+					case "add_date":
+						rec.CreatedAt = parseTime(string(val))
+					case "tags":
+						rec.Tags = append(rec.Tags, splitTags(string(val))...)
+					case "shortcuturl":
+						rec.Alias = strings.TrimSpace(string(val))
+					}
+				}
+				for _, folder := range folders {
+					if folder != "" {
+						rec.Tags = append(rec.Tags, folder)
+					}
+				}
+				current = &rec
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "h3":
+				inFolderTitle = false
+			case "dl":
+				if len(folders) > 0 {
+					folders = folders[:len(folders)-1]
+				}
+			case "a":
+				if current != nil && current.OriginalURL != "" {
+					records = append(records, *current)
+				}
+				current = nil
+			}
+
+		case html.TextToken:
+			if inFolderTitle {
+				pendingFolder += strings.TrimSpace(string(z.Text()))
+			}
+		}
+	}
+}
+
+func normalizeHeader(name string) string {
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+func splitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == '|'
+	}) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"01/02/2006 15:04",
+	"01/02/2006",
+}
+
+// parseTime accepts common date layouts and Unix timestamps. Unparseable
+// values are ignored rather than failing the row.
+func parseTime(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		t := time.Unix(secs, 0).UTC()
+		return &t
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// summary renders records compactly, one per line, with times in UTC.
+func summary(records []Record) string {
+	var b strings.Builder
+	for _, r := range records {
+		created := ""
+		if r.CreatedAt != nil {
+			created = r.CreatedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(&b, "%d %s alias=%q tags=%q created=%s\n", r.Row, r.OriginalURL, r.Alias, r.Tags, created)
+	}
+	return b.String()
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			"plain header",
+			"url,alias,tags,created_at\n" +
+				"https://a.com,a1,\"x, y\",2024-01-02T03:04:05Z\n" +
+				"https://b.com,,,\n",
+			"2 https://a.com alias=\"a1\" tags=[\"x\" \"y\"] created=2024-01-02T03:04:05Z\n" +
+				"3 https://b.com alias=\"\" tags=[] created=\n",
+		},
+		{
+			"byte order mark, spaced and dashed names",
+			"\ufeff Long-URL ,Short Code,Date\n" +
+				"https://a.com,abc,01/02/2024\n",
+			"2 https://a.com alias=\"abc\" tags=[] created=2024-01-02T00:00:00Z\n",
+		},
+		{
+			"quoted fields",
+			"destination,slug,tag\n" +
+				"\"https://a.com/?q=1,2\",\"my \"\"code\"\"\",\"one|two\"\n" +
+				"\"https://b.com/\nnext\",b,\n" +
+				"https://c.com/\"x\",c,\n",
+			"2 https://a.com/?q=1,2 alias=\"my \\\"code\\\"\" tags=[\"one\" \"two\"] created=\n" +
+				"3 https://b.com/\nnext alias=\"b\" tags=[] created=\n" +
+				"5 https://c.com/\"x\" alias=\"c\" tags=[] created=\n",
+		},
+		{
+			"short and empty rows",
+			"url,alias,tags\n" +
+				"https://a.com\n" +
+				",orphan,t\n" +
+				"   \n" +
+				"https://b.com,b,t,extra\n",
+			"2 https://a.com alias=\"\" tags=[] created=\n" +
+				"5 https://b.com alias=\"b\" tags=[\"t\"] created=\n",
+		},
+		{
+			"unix and unparseable dates",
+			"url,created\nhttps://a.com,1700000000\nhttps://b.com,yesterday\n",
+			"2 https://a.com alias=\"\" tags=[] created=2023-11-14T22:13:20Z\n" +
+				"3 https://b.com alias=\"\" tags=[] created=\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := Parse(FormatCSV, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := summary(records); got != tt.want {
+				t.Errorf("Parse =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	tests := map[string]string{
+		"empty file":        "",
+		"no original field": "alias,tags\nabc,x\n",
+		"semicolons":        "Original URL;alias\nhttps://a.com;a\n",
+	}
+	for name, input := range tests {
+		if _, err := Parse(FormatCSV, strings.NewReader(input)); err == nil {
+			t.Errorf("%s: Parse accepted %q", name, input)
+		}
+	}
+
+	r := io.MultiReader(strings.NewReader("url\nhttps://a.com\n"), iotest.ErrReader(errors.New("connection reset")))
+	if _, err := Parse(FormatCSV, r); err == nil {
+		t.Error("Parse ignored a read error")
+	}
+}
+
+func TestParseBitly(t *testing.T) {
+	input := "Bitlink,Custom Bitlinks,Long URL,Created At,Tags\n" +
+		"bit.ly/3xYz,\"https://bit.ly/mine, bit.ly/other\",https://a.com,2024-03-04 05:06:07,news;tech\n" +
+		"https://bit.ly/gen,,https://b.com,,\n" +
+		"bit.ly/gen2,,,,\n"
+	want := "2 https://a.com alias=\"mine\" tags=[\"news\" \"tech\"] created=2024-03-04T05:06:07Z\n" +
+		"3 https://b.com alias=\"gen\" tags=[] created=\n"
+
+	records, err := Parse(FormatBitly, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := summary(records); got != want {
+		t.Errorf("Parse =\n%s\nwant\n%s", got, want)
+	}
+
+	// The generic "url" column is not a Bitly one.
+	if _, err := Parse(FormatBitly, strings.NewReader("url\nhttps://a.com\n")); err == nil {
+		t.Error("Parse accepted a Bitly export without a long URL column")
+	}
+}
+
+func TestBitlyAlias(t *testing.T) {
+	tests := map[string]string{
+		"bit.ly/abc":               "abc",
+		"https://bit.ly/abc":       "abc",
+		"bit.ly/abc | bit.ly/def":  "abc",
+		"  ":                       "",
+		"https://bit.ly/":          "",
+		"https://custom.link/a/b/": "a/b",
+	}
+	for in, want := range tests {
+		if got := bitlyAlias(in); got != want {
+			t.Errorf("bitlyAlias(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseBookmarks(t *testing.T) {
+	input := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><A HREF="https://top.example" ADD_DATE="1700000000">Top</A>
+    <DT><H3 ADD_DATE="1700000000">Work</H3>
+    <DL><p>
+        <DT><A HREF="https://work.example" TAGS="a,b" SHORTCUTURL="wk">Work</A>
+        <DT><H3>Docs &amp; Specs</H3>
+        <DL><p>
+            <DT><A href=" https://docs.example/x?y=1&amp;z=2 ">Docs</A>
+            <DT><A>No link</A>
+        </DL><p>
+        <DT><A HREF="https://after.example">After nested</A>
+    </DL><p>
+    <DT><H3></H3>
+    <DL><p>
+        <DT><A HREF="https://untitled.example">Untitled folder</A>
+    </DL><p>
+    <DT><A HREF="https://last.example">Last</A>
+</DL><p>
+`
+	want := "1 https://top.example alias=\"\" tags=[] created=2023-11-14T22:13:20Z\n" +
+		"2 https://work.example alias=\"wk\" tags=[\"a\" \"b\" \"Work\"] created=\n" +
+		"3 https://docs.example/x?y=1&z=2 alias=\"\" tags=[\"Work\" \"Docs & Specs\"] created=\n" +
+		"5 https://after.example alias=\"\" tags=[\"Work\"] created=\n" +
+		"6 https://untitled.example alias=\"\" tags=[] created=\n" +
+		"7 https://last.example alias=\"\" tags=[] created=\n"
+
+	records, err := Parse(FormatBookmarks, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := summary(records); got != want {
+		t.Errorf("Parse =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	if _, err := Parse("xlsx", strings.NewReader("")); err == nil {
+		t.Error("Parse accepted an unknown format")
+	}
+}
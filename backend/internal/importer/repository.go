@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+type Repository interface {
+	Create(j *Job) (int64, error)
+	GetByID(id int64) (*Job, error)
+	ListByUser(userID int64) ([]*Job, error)
+	UpdateProgress(j *Job) error
+	FailInterrupted() (int64, error)
+	// CountImportedToday counts rows imported today into a user's personal
+	// links, or into workspaceID when set.
+	CountImportedToday(userID int64, workspaceID *int64) (int, error)
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+const selectJob = `
+	SELECT id, user_id, workspace_id, domain_id, format, status, total, processed,
+	       created, duplicates, failed, conflicts, error, created_at, finished_at
+	FROM import_jobs`
+
+func scanJob(row interface{ Scan(dest ...any) error }) (*Job, error) {
+	j := &Job{}
+	var conflicts []byte
+	err := row.Scan(
+		&j.ID, &j.UserID, &j.WorkspaceID, &j.DomainID, &j.Format, &j.Status, &j.Total, &j.Processed,
+		&j.Created, &j.Duplicates, &j.Failed, &conflicts, &j.Error, &j.CreatedAt, &j.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(conflicts, &j.Conflicts); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (r *repository) Create(j *Job) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(`
+		INSERT INTO import_jobs (user_id, workspace_id, domain_id, format, status, total)
+		VALUES ($1,$2,$3,$4,$5,$6)
+		RETURNING id, created_at`,
+		j.UserID, j.WorkspaceID, j.DomainID, j.Format, j.Status, j.Total,
+	).Scan(&id, &j.CreatedAt)
+	return id, err
+}
+
+func (r *repository) GetByID(id int64) (*Job, error) {
+	j, err := scanJob(r.db.QueryRow(selectJob+" WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return j, err
+}
+
+func (r *repository) ListByUser(userID int64) ([]*Job, error) {
+	rows, err := r.db.Query(selectJob+" WHERE user_id = $1 ORDER BY created_at DESC LIMIT 50", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, j)
+	}
+	return list, rows.Err()
+}
+
+// UpdateProgress stores counters, conflicts and status. finished_at is set
+// once the job leaves the queued/running states.
+func (r *repository) UpdateProgress(j *Job) error {
+	conflicts, err := json.Marshal(j.Conflicts)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		UPDATE import_jobs
+		SET status=$2, processed=$3, created=$4, duplicates=$5, failed=$6, conflicts=$7, error=$8,
+		    finished_at = CASE WHEN $2 IN ('completed','failed') THEN NOW() ELSE NULL END
+		WHERE id=$1`,
+		j.ID, j.Status, j.Processed, j.Created, j.Duplicates, j.Failed, string(conflicts), j.Error,
+	)
+	return err
+}
+
+// FailInterrupted marks jobs that were queued or running when the server
+// stopped. Their records only lived in memory and cannot be resumed.
+func (r *repository) FailInterrupted() (int64, error) {
+	res, err := r.db.Exec(`
+		UPDATE import_jobs
+		SET status='failed', error='interrupted by server restart', finished_at=NOW()
+		WHERE status IN ('queued','running')`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CountImportedToday charges each job its full size, except failed jobs,
+// which only used up the links they created.
+func (r *repository) CountImportedToday(userID int64, workspaceID *int64) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN status = $3 THEN created ELSE total END), 0)
+		FROM import_jobs
+		WHERE (($2::int IS NULL AND user_id = $1 AND workspace_id IS NULL) OR workspace_id = $2)
+		  AND created_at >= CURRENT_DATE`,
+		userID, workspaceID, StatusFailed,
+	).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,243 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"url-shortener/internal/url"
+	"url-shortener/internal/workspace"
+)
+
+const (
+	maxRecordsPerImport = 10000
+	maxConflicts        = 1000
+	progressEvery       = 50
+	queueSize           = 16
+
+	// maxImportedPerDay is how many rows an account, or a workspace, may
+	// import a day. Imports have their own allowance rather than the
+	// 100-a-day quota for links created one at a time.
+	maxImportedPerDay = 20000
+)
+
+var (
+	ErrJobNotFound       = errors.New("import job not found")
+	ErrQueueFull         = errors.New("too many imports in progress, try again later")
+	ErrEmptyImport       = errors.New("file contains no links")
+	ErrAllowanceExceeded = errors.New("daily import allowance exceeded")
+)
+
+type StartInput struct {
+	Format      string
+	WorkspaceID *int64
+	DomainID    *int64
+}
+
+type Service interface {
+	Start(userID int64, in StartInput, r io.Reader) (*Job, error)
+	Get(userID, id int64) (*Job, error)
+	List(userID int64) ([]*Job, error)
+	Run(ctx context.Context)
+}
+
+type task struct {
+	job     *Job
+	records []Record
+}
+
+type service struct {
+	repo             Repository
+	urlService       url.Service
+	workspaceService workspace.Service
+	queue            chan task
+}
+
+func NewService(repo Repository, urlService url.Service, workspaceService workspace.Service) Service {
+	return &service{
+		repo:             repo,
+		urlService:       urlService,
+		workspaceService: workspaceService,
+		queue:            make(chan task, queueSize),
+	}
+}
+
+// Start parses the file up front so format errors are reported immediately,
+// then queues the records for the background worker.
+func (s *service) Start(userID int64, in StartInput, r io.Reader) (*Job, error) {
+	if in.WorkspaceID != nil {
+		if _, err := s.workspaceService.Authorize(userID, *in.WorkspaceID, workspace.PermEdit); err != nil {
+			return nil, err
+		}
+	}
+
+	records, err := Parse(in.Format, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrEmptyImport
+	}
+	if len(records) > maxRecordsPerImport {
+		return nil, fmt.Errorf("imports are limited to %d links per file", maxRecordsPerImport)
+	}
+
+	imported, err := s.repo.CountImportedToday(userID, in.WorkspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check import allowance: %w", err)
+	}
+	if imported+len(records) > maxImportedPerDay {
+		return nil, fmt.Errorf("%w: %d of %d rows left today", ErrAllowanceExceeded, max(maxImportedPerDay-imported, 0), maxImportedPerDay)
+	}
+
+	job := &Job{
+		UserID:      userID,
+		WorkspaceID: in.WorkspaceID,
+		DomainID:    in.DomainID,
+		Format:      in.Format,
+		Status:      StatusQueued,
+		Total:       len(records),
+		Conflicts:   []Conflict{},
+	}
+	id, err := s.repo.Create(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+	job.ID = id
+
+	select {
+	case s.queue <- task{job: job, records: records}:
+	default:
+		job.Status = StatusFailed
+		job.Error = ErrQueueFull.Error()
+		if err := s.repo.UpdateProgress(job); err != nil {
+			log.Printf("❌ Failed to update import job %d: %v", job.ID, err)
+		}
+		return nil, ErrQueueFull
+	}
+	return job, nil
+}
+
+func (s *service) Get(userID, id int64) (*Job, error) {
+	job, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.UserID != userID {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+func (s *service) List(userID int64) ([]*Job, error) {
+	return s.repo.ListByUser(userID)
+}
+
+// Run processes queued imports one at a time until ctx is cancelled.
+func (s *service) Run(ctx context.Context) {
+	if n, err := s.repo.FailInterrupted(); err != nil {
+		log.Printf("❌ Failed to clean up interrupted imports: %v", err)
+	} else if n > 0 {
+		log.Printf("⚠️ Marked %d interrupted import(s) as failed", n)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-s.queue:
+			s.process(t.job, t.records)
+		}
+	}
+}
+
+func (s *service) process(job *Job, records []Record) {
+	job.Status = StatusRunning
+	s.save(job)
+
+	for i, rec := range records {
+		s.importRecord(job, rec)
+		job.Processed++
+		if (i+1)%progressEvery == 0 {
+			s.save(job)
+		}
+	}
+
+	job.Status = StatusCompleted
+	s.save(job)
+	log.Printf("✅ Import %d finished: %d created, %d duplicates, %d failed",
+		job.ID, job.Created, job.Duplicates, job.Failed)
+}
+
+// importRecord creates one link. A taken or invalid alias falls back to a
+// generated code and is reported as a conflict; duplicates of existing
+// links are skipped.
+func (s *service) importRecord(job *Job, rec Record) {
+	in := url.CreateURLInput{
+		OriginalURL: rec.OriginalURL,
+		WorkspaceID: job.WorkspaceID,
+		DomainID:    job.DomainID,
+		Alias:       rec.Alias,
+		Tags:        cleanTags(rec.Tags),
+		CreatedAt:   rec.CreatedAt,
+	}
+
+	u, err := s.urlService.ImportURL(job.UserID, in)
+	if err != nil && in.Alias != "" && (errors.Is(err, url.ErrAliasTaken) || errors.Is(err, url.ErrInvalidAlias)) {
+		reason := err.Error()
+		in.Alias = ""
+		u, err = s.urlService.ImportURL(job.UserID, in)
+		if err == nil {
+			job.Created++
+			s.conflict(job, rec, reason+", generated a new code", u.ShortCode)
+			return
+		}
+	}
+
+	switch {
+	case err == nil:
+		job.Created++
+	case errors.Is(err, url.ErrDuplicateURL):
+		job.Duplicates++
+		s.conflict(job, rec, "link already exists", u.ShortCode)
+	default:
+		job.Failed++
+		s.conflict(job, rec, err.Error(), "")
+	}
+}
+
+func (s *service) conflict(job *Job, rec Record, reason, shortCode string) {
+	if len(job.Conflicts) >= maxConflicts {
+		return
+	}
+	job.Conflicts = append(job.Conflicts, Conflict{
+		Row:         rec.Row,
+		OriginalURL: rec.OriginalURL,
+		Alias:       rec.Alias,
+		Reason:      reason,
+		ShortCode:   shortCode,
+	})
+}
+
+func (s *service) save(job *Job) {
+	if err := s.repo.UpdateProgress(job); err != nil {
+		log.Printf("❌ Failed to update import job %d: %v", job.ID, err)
+	}
+}
+
+// cleanTags drops tags the url package would reject so that a long folder
+// name does not fail the whole row.
+func cleanTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		if len(tag) > url.MaxTagLength {
+			continue
+		}
+		out = append(out, tag)
+		if len(out) == url.MaxTags {
+			break
+		}
+	}
+	return out
+}
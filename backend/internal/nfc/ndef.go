@@ -0,0 +1,92 @@
+package nfc
+
+import "strings"
+
+// uriPrefixes are the abbreviations of the NFC Forum URI Record Type
+// Definition; a prefix is replaced by its index in the record.
+var uriPrefixes = []string{
+	"",
+	"http://www.",
+	"https://www.",
+	"http://",
+	"https://",
+	"tel:",
+	"mailto:",
+	"ftp://anonymous:anonymous@",
+	"ftp://ftp.",
+	"ftps://",
+	"sftp://",
+	"smb://",
+	"nfs://",
+	"ftp://",
+	"dav://",
+	"news:",
+	"telnet://",
+	"imap:",
+	"rtsp://",
+	"urn:",
+	"pop:",
+	"sip:",
+	"sips:",
+	"tftp:",
+	"btspp://",
+	"btl2cap://",
+	"btgoep://",
+	"tcpobex://",
+	"irdaobex://",
+	"file://",
+	"urn:epc:id:",
+	"urn:epc:tag:",
+	"urn:epc:pat:",
+	"urn:epc:raw:",
+	"urn:epc:",
+	"urn:nfc:",
+}
+
+// Record header flags and the well-known type name format.
+const (
+	flagMB       = 0x80
+	flagME       = 0x40
+	flagSR       = 0x10
+	tnfWellKnown = 0x01
+)
+
+// URIMessage returns an NDEF message holding a single URI record for uri,
+// with the longest matching prefix abbreviated. Short records are used
+// whenever the payload fits in 255 bytes.
+func URIMessage(uri string) []byte {
+	code := 0
+	for i, prefix := range uriPrefixes {
+		if len(prefix) > len(uriPrefixes[code]) && strings.HasPrefix(uri, prefix) {
+			code = i
+		}
+	}
+	payload := append([]byte{byte(code)}, uri[len(uriPrefixes[code]):]...)
+
+	header := byte(flagMB | flagME | tnfWellKnown)
+	msg := []byte{0, 1}
+	if len(payload) <= 0xff {
+		header |= flagSR
+		msg = append(msg, byte(len(payload)))
+	} else {
+		n := len(payload)
+		msg = append(msg, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	msg[0] = header
+	msg = append(msg, 'U')
+	return append(msg, payload...)
+}
+
+// TLV wraps an NDEF message in the NDEF Message TLV and Terminator TLV used
+// in the data area of NFC Forum Type 2 tags such as NTAG21x, for writers
+// that take raw memory contents.
+func TLV(msg []byte) []byte {
+	var out []byte
+	if len(msg) < 0xff {
+		out = append(out, 0x03, byte(len(msg)))
+	} else {
+		out = append(out, 0x03, 0xff, byte(len(msg)>>8), byte(len(msg)))
+	}
+	out = append(out, msg...)
+	return append(out, 0xfe)
+}
@@ -0,0 +1,83 @@
+package nfc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestURIMessage(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		// Header, type length, payload length, type "U", prefix code, rest.
+		{"https://www.example.com", "d1 01 0c 55 02" + hex.EncodeToString([]byte("example.com"))},
+		{"http://www.example.com/a", "d1 01 0e 55 01" + hex.EncodeToString([]byte("example.com/a"))},
+		{"https://sho.rt/abc", "d1 01 0b 55 04" + hex.EncodeToString([]byte("sho.rt/abc"))},
+		{"http://sho.rt/abc", "d1 01 0b 55 03" + hex.EncodeToString([]byte("sho.rt/abc"))},
+		{"tel:+15551234", "d1 01 0a 55 05" + hex.EncodeToString([]byte("+15551234"))},
+		{"mailto:a@b.c", "d1 01 06 55 06" + hex.EncodeToString([]byte("a@b.c"))},
+		// The longest of several matching prefixes wins.
+		{"urn:epc:id:sgtin", "d1 01 06 55 1e" + hex.EncodeToString([]byte("sgtin"))},
+		{"urn:epc:x", "d1 01 02 55 22 78"},
+		{"urn:x", "d1 01 02 55 13 78"},
+		// Unknown schemes are stored in full.
+		{"geo:1,2", "d1 01 08 55 00" + hex.EncodeToString([]byte("geo:1,2"))},
+		{"", "d1 01 01 55 00"},
+	}
+	for _, tt := range tests {
+		if got, want := URIMessage(tt.uri), unhex(t, tt.want); !bytes.Equal(got, want) {
+			t.Errorf("URIMessage(%q) = % x, want % x", tt.uri, got, want)
+		}
+	}
+}
+
+func TestURIMessageLongRecord(t *testing.T) {
+	tests := []struct {
+		rest   int
+		header string
+	}{
+		{254, "d1 01 ff 55 04"},
+		{255, "c1 01 00 00 01 00 55 04"},
+		{70000, "c1 01 00 01 11 71 55 04"},
+	}
+	for _, tt := range tests {
+		rest := strings.Repeat("a", tt.rest)
+		got := URIMessage("https://" + rest)
+		want := append(unhex(t, tt.header), rest...)
+		if !bytes.Equal(got, want) {
+			t.Errorf("%d-byte URI: header % x, want % x", tt.rest, got[:len(tt.header)/3+1], unhex(t, tt.header))
+		}
+	}
+}
+
+func TestTLV(t *testing.T) {
+	tests := []struct {
+		n      int
+		header string
+	}{
+		{0, "03 00"},
+		{12, "03 0c"},
+		{254, "03 fe"},
+		{255, "03 ff 00 ff"},
+		{1000, "03 ff 03 e8"},
+	}
+	for _, tt := range tests {
+		msg := bytes.Repeat([]byte{0xaa}, tt.n)
+		want := append(append(unhex(t, tt.header), msg...), 0xfe)
+		if got := TLV(msg); !bytes.Equal(got, want) {
+			t.Errorf("TLV of %d bytes = % x..., want % x...", tt.n, got[:min(len(got), 4)], want[:min(len(want), 4)])
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"url-shortener/internal/url"
+)
+
+// ExpiryJob periodically warns owners about links that expire within the
+// configured window. Each link is notified once per expiry date.
+type ExpiryJob struct {
+	urlRepo  url.Repository
+	service  Service
+	window   time.Duration
+	interval time.Duration
+}
+
+func NewExpiryJob(urlRepo url.Repository, service Service, window, interval time.Duration) *ExpiryJob {
+	return &ExpiryJob{urlRepo: urlRepo, service: service, window: window, interval: interval}
+}
+
+func (j *ExpiryJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.RunOnce(); err != nil {
+			log.Printf("❌ Expiry notification job failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *ExpiryJob) RunOnce() error {
+	links, err := j.urlRepo.ListExpiringBefore(time.Now().Add(j.window))
+	if err != nil {
+		return fmt.Errorf("failed to list expiring links: %w", err)
+	}
+
+	for _, u := range links {
+		shortURL := u.ShortURL()
+		subject := "Your short link is about to expire"
+		message := fmt.Sprintf(
+			"Your link %s (%s) expires on %s. Renew it to keep it working.",
+			shortURL, u.OriginalURL, u.ExpiresAt.UTC().Format("2006-01-02 15:04 UTC"),
+		)
+
+		id := u.ID
+		if err := j.service.Notify(u.UserID, &id, KindLinkExpiring, subject, message); err != nil {
+			log.Printf("❌ Failed to notify user %d about link %d: %v", u.UserID, u.ID, err)
+			continue
+		}
+		if err := j.urlRepo.MarkExpiryNotified(u.ID); err != nil {
+			log.Printf("❌ Failed to mark link %d as notified: %v", u.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package notification
+
+import "time"
+
+const (
+	KindLinkExpiring = "link_expiring"
+	KindLinkTransfer = "link_transfer"
+)
+
+type Notification struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	Kind      string     `json:"kind"`
+	URLID     *int64     `json:"url_id,omitempty"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,63 @@
+package notification
+
+import "database/sql"
+
+type Repository interface {
+	Create(n *Notification) (int64, error)
+	ListByUser(userID int64, unreadOnly bool) ([]*Notification, error)
+	MarkRead(userID, id int64) (bool, error)
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(n *Notification) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(
+		"INSERT INTO notifications (user_id, kind, url_id, message) VALUES ($1,$2,$3,$4) RETURNING id",
+		n.UserID, n.Kind, n.URLID, n.Message,
+	).Scan(&id)
+	return id, err
+}
+
+func (r *repository) ListByUser(userID int64, unreadOnly bool) ([]*Notification, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, kind, url_id, message, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT 100`,
+		userID, unreadOnly,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Notification
+	for rows.Next() {
+		n := &Notification{}
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &n.URLID, &n.Message, &n.ReadAt, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, n)
+	}
+	return list, rows.Err()
+}
+
+func (r *repository) MarkRead(userID, id int64) (bool, error) {
+	res, err := r.db.Exec(
+		"UPDATE notifications SET read_at=NOW() WHERE id=$1 AND user_id=$2 AND read_at IS NULL",
+		id, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,68 @@
+package notification
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+)
+
+// Sender delivers outbound email. Swap implementations to change transport.
+type Sender interface {
+	Send(to, subject, body string) error
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type smtpSender struct {
+	cfg SMTPConfig
+}
+
+func NewSMTPSender(cfg SMTPConfig) Sender {
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	return &smtpSender{cfg: cfg}
+}
+
+func (s *smtpSender) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + s.cfg.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	addr := s.cfg.Host + ":" + s.cfg.Port
+	if err := smtp.SendMail(addr, auth, s.cfg.From, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("smtp send to %s: %w", to, err)
+	}
+	return nil
+}
+
+type logSender struct{}
+
+// NewLogSender returns a Sender that only logs messages. Used when SMTP is
+// not configured.
+func NewLogSender() Sender {
+	return logSender{}
+}
+
+func (logSender) Send(to, subject, body string) error {
+	log.Printf("📧 email to %s: %s", to, subject)
+	return nil
+}
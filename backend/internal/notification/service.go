@@ -0,0 +1,70 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"url-shortener/internal/user"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+type Service interface {
+	Notify(userID int64, urlID *int64, kind, subject, message string) error
+	List(userID int64, unreadOnly bool) ([]*Notification, error)
+	MarkRead(userID, id int64) error
+}
+
+type service struct {
+	repo     Repository
+	userRepo user.Repository
+	sender   Sender
+}
+
+func NewService(repo Repository, userRepo user.Repository, sender Sender) Service {
+	return &service{repo: repo, userRepo: userRepo, sender: sender}
+}
+
+// Notify stores an in-app notification and emails the user if they have an
+// address on file. Once the notification is stored it counts as delivered:
+// email failures are only logged, so callers never retry and duplicate it.
+func (s *service) Notify(userID int64, urlID *int64, kind, subject, message string) error {
+	_, err := s.repo.Create(&Notification{
+		UserID:  userID,
+		Kind:    kind,
+		URLID:   urlID,
+		Message: message,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		log.Printf("❌ Failed to load user %d for email: %v", userID, err)
+		return nil
+	}
+	if u == nil || u.Email == "" {
+		return nil
+	}
+
+	if err := s.sender.Send(u.Email, subject, message); err != nil {
+		log.Printf("❌ Failed to email user %d: %v", userID, err)
+	}
+	return nil
+}
+
+func (s *service) List(userID int64, unreadOnly bool) ([]*Notification, error) {
+	return s.repo.ListByUser(userID, unreadOnly)
+}
+
+func (s *service) MarkRead(userID, id int64) error {
+	ok, err := s.repo.MarkRead(userID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package payload
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Files use the full formats with folded lines; QR codes drop optional
+// properties and folding to keep the code small enough to scan.
+
+func (v *VCard) fullName() string {
+	return strings.TrimSpace(v.FirstName + " " + v.LastName)
+}
+
+func (v *VCard) encode(file bool) string {
+	var b lineWriter
+	b.fold = file
+	b.line("BEGIN:VCARD")
+	b.line("VERSION:3.0")
+	b.line("N:" + escapeText(v.LastName) + ";" + escapeText(v.FirstName) + ";;;")
+	fn := v.fullName()
+	if fn == "" {
+		fn = v.Organization
+	}
+	b.line("FN:" + escapeText(fn))
+	b.prop("ORG", v.Organization)
+	b.prop("TITLE", v.Title)
+	b.uri("TEL;TYPE=CELL", v.Phone)
+	b.uri("EMAIL", v.Email)
+	b.uri("URL", v.Website)
+	if v.Street != "" || v.City != "" || v.Region != "" || v.PostalCode != "" || v.Country != "" {
+		b.line("ADR;TYPE=WORK:;;" + strings.Join([]string{
+			escapeText(v.Street), escapeText(v.City), escapeText(v.Region),
+			escapeText(v.PostalCode), escapeText(v.Country),
+		}, ";"))
+	}
+	b.prop("NOTE", v.Note)
+	b.line("END:VCARD")
+	return b.String()
+}
+
+// encode writes the network in the WIFI: format understood by the camera
+// apps of iOS and Android.
+func (w *WiFi) encode() string {
+	var b strings.Builder
+	b.WriteString("WIFI:T:" + w.Security + ";S:" + escapeWiFi(w.SSID) + ";")
+	if w.Security != SecurityNone {
+		b.WriteString("P:" + escapeWiFi(w.Password) + ";")
+	}
+	if w.Hidden {
+		b.WriteString("H:true;")
+	}
+	b.WriteString(";")
+	return b.String()
+}
+
+// encode writes a bare VEVENT for QR codes, or a whole VCALENDAR for .ics
+// files, which also need a UID and DTSTAMP.
+func (e *Event) encode(file bool, uid string, stamp time.Time) string {
+	var b lineWriter
+	b.fold = file
+	if file {
+		b.line("BEGIN:VCALENDAR")
+		b.line("VERSION:2.0")
+		b.line("PRODID:-//shorty//payloads//EN")
+	}
+	b.line("BEGIN:VEVENT")
+	if file {
+		b.line("UID:" + uid)
+		b.line("DTSTAMP:" + icalTime(stamp))
+	}
+	if e.AllDay {
+		b.line("DTSTART;VALUE=DATE:" + e.Start.Format("20060102"))
+		b.line("DTEND;VALUE=DATE:" + e.End.Format("20060102"))
+	} else {
+		b.line("DTSTART:" + icalTime(e.Start))
+		b.line("DTEND:" + icalTime(e.End))
+	}
+	b.prop("SUMMARY", e.Summary)
+	b.prop("LOCATION", e.Location)
+	b.prop("DESCRIPTION", e.Description)
+	b.uri("URL", e.URL)
+	b.line("END:VEVENT")
+	if file {
+		b.line("END:VCALENDAR")
+	}
+	return b.String()
+}
+
+func icalTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escapeText escapes a vCard or iCalendar TEXT value.
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+var wifiEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`)
+
+func escapeWiFi(s string) string {
+	return wifiEscaper.Replace(s)
+}
+
+// lineWriter joins content lines with CRLF, optionally folding them at 75
+// octets as RFC 5545 and RFC 6350 ask of files.
+type lineWriter struct {
+	strings.Builder
+	fold bool
+}
+
+func (w *lineWriter) prop(name, value string) {
+	if value != "" {
+		w.line(name + ":" + escapeText(value))
+	}
+}
+
+// uri writes a value that is not TEXT and so is not escaped; line breaks
+// are still dropped so a value cannot start a property of its own.
+func (w *lineWriter) uri(name, value string) {
+	if value = strings.Join(strings.Fields(value), " "); value != "" {
+		w.line(name + ":" + value)
+	}
+}
+
+func (w *lineWriter) line(s string) {
+	limit := 75
+	for w.fold && len(s) > limit {
+		// Never split a UTF-8 sequence.
+		cut := limit
+		for !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space, which counts.
+		limit = 74
+	}
+	w.WriteString(s + "\r\n")
+}
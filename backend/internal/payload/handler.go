@@ -0,0 +1,217 @@
+package payload
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"url-shortener/internal/qr"
+	"url-shortener/internal/url"
+	"url-shortener/internal/workspace"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrPayloadNotFound), errors.Is(err, url.ErrURLNotFound),
+		errors.Is(err, workspace.ErrWorkspaceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, workspace.ErrForbidden), errors.Is(err, url.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, url.ErrAliasTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// POST /api/payloads
+func (h *Handler) Create(c *gin.Context) {
+	var req Input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	p, err := h.service.Create(userID.(int64), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, p)
+}
+
+// GET /api/payloads?workspace_id=1
+func (h *Handler) List(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var workspaceID *int64
+	if raw := c.Query("workspace_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace_id"})
+			return
+		}
+		workspaceID = &id
+	}
+
+	list, err := h.service.List(userID.(int64), workspaceID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if list == nil {
+		list = []*Payload{}
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GET /api/payloads/:id
+func (h *Handler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	p, err := h.service.Get(userID.(int64), id)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// PUT /api/payloads/:id
+func (h *Handler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req Input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	p, err := h.service.Update(userID.(int64), id, req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// DELETE /api/payloads/:id
+func (h *Handler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := h.service.Delete(userID.(int64), id); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "QR payload deleted"})
+}
+
+// GET /api/payloads/:id/qr?format=png|svg|pdf|eps&dpi=&width_mm=
+func (h *Handler) DownloadQR(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	opts := qr.Options{Format: strings.ToLower(c.Query("format"))}
+	if raw := c.Query("dpi"); raw != "" {
+		if opts.DPI, err = strconv.Atoi(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dpi"})
+			return
+		}
+	}
+	if raw := c.Query("width_mm"); raw != "" {
+		if opts.WidthMM, err = strconv.ParseFloat(raw, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid width_mm"})
+			return
+		}
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	out, filename, err := h.service.RenderQR(userID.(int64), id, opts)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, out.ContentType, out.Data)
+}
+
+// GET /api/payloads/files/:file, where file is <token>.vcf or <token>.ics.
+// Public: it is the destination of a dynamic payload's short link.
+func (h *Handler) File(c *gin.Context) {
+	name := c.Param("file")
+	f, err := h.service.File(strings.TrimSuffix(name, path.Ext(name)))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Content-Disposition", `inline; filename="`+f.Name+`"`)
+	c.Data(http.StatusOK, f.ContentType, f.Data)
+}
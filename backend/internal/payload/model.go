@@ -0,0 +1,231 @@
+package payload
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	TypeVCard = "vcard"
+	TypeWiFi  = "wifi"
+	TypeEvent = "event"
+)
+
+// Wi-Fi security types as spelled in WIFI: payloads.
+const (
+	SecurityWPA  = "WPA"
+	SecurityWEP  = "WEP"
+	SecurityNone = "nopass"
+)
+
+type VCard struct {
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Organization string `json:"organization"`
+	Title        string `json:"title"`
+	Phone        string `json:"phone"`
+	Email        string `json:"email"`
+	Website      string `json:"website"`
+	Street       string `json:"street"`
+	City         string `json:"city"`
+	Region       string `json:"region"`
+	PostalCode   string `json:"postal_code"`
+	Country      string `json:"country"`
+	Note         string `json:"note"`
+}
+
+type WiFi struct {
+	SSID     string `json:"ssid"`
+	Password string `json:"password"`
+	Security string `json:"security"`
+	Hidden   bool   `json:"hidden"`
+}
+
+// Event is a calendar entry. All-day events use only the dates of Start and
+// End, with End exclusive as in iCalendar.
+type Event struct {
+	Summary     string    `json:"summary"`
+	Location    string    `json:"location"`
+	Description string    `json:"description"`
+	URL         string    `json:"url"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	AllDay      bool      `json:"all_day"`
+}
+
+// Content holds the fields of one payload type; exactly one is set. It is
+// stored as JSONB and inlined in API responses.
+type Content struct {
+	VCard *VCard `json:"vcard,omitempty"`
+	WiFi  *WiFi  `json:"wifi,omitempty"`
+	Event *Event `json:"event,omitempty"`
+}
+
+// Payload is a QR code for something other than a web link. A static
+// payload encodes its content directly; a dynamic one encodes a short link
+// that serves the content as a .vcf or .ics file, so scans are counted as
+// clicks and the content can change after printing.
+type Payload struct {
+	ID          int64  `json:"id"`
+	UserID      int64  `json:"user_id"`
+	WorkspaceID *int64 `json:"workspace_id,omitempty"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Content
+	Dynamic bool   `json:"dynamic"`
+	URLID   *int64 `json:"url_id,omitempty"`
+	// Token names the public file a dynamic payload's link points at.
+	Token     string    `json:"-"`
+	ShortURL  string    `json:"short_url,omitempty"`
+	QRURL     string    `json:"qr_url,omitempty"`
+	FileURL   string    `json:"file_url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	shortCode string
+	domain    string
+}
+
+// File is a dynamic payload's content as served to scanners.
+type File struct {
+	Name        string
+	Data        []byte
+	ContentType string
+}
+
+const (
+	MaxNameLength  = 100
+	maxFieldLength = 500
+)
+
+// normalize checks that Content holds exactly the fields for typ and
+// validates them.
+func (c *Content) normalize(typ string) error {
+	set := 0
+	for _, ok := range []bool{c.VCard != nil, c.WiFi != nil, c.Event != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("provide exactly one of vcard, wifi or event")
+	}
+
+	switch typ {
+	case TypeVCard:
+		if c.VCard == nil {
+			return errors.New("vcard payloads need a vcard object")
+		}
+		return c.VCard.normalize()
+	case TypeWiFi:
+		if c.WiFi == nil {
+			return errors.New("wifi payloads need a wifi object")
+		}
+		return c.WiFi.normalize()
+	case TypeEvent:
+		if c.Event == nil {
+			return errors.New("event payloads need an event object")
+		}
+		return c.Event.normalize()
+	}
+	return errors.New("type must be one of vcard, wifi, event")
+}
+
+// defaultName names a payload after its content.
+func (c *Content) defaultName() string {
+	switch {
+	case c.VCard != nil:
+		if name := c.VCard.fullName(); name != "" {
+			return name
+		}
+		return c.VCard.Organization
+	case c.WiFi != nil:
+		return c.WiFi.SSID
+	case c.Event != nil:
+		return c.Event.Summary
+	}
+	return ""
+}
+
+func (v *VCard) normalize() error {
+	for _, f := range []*string{
+		&v.FirstName, &v.LastName, &v.Organization, &v.Title, &v.Phone, &v.Email, &v.Website,
+		&v.Street, &v.City, &v.Region, &v.PostalCode, &v.Country, &v.Note,
+	} {
+		*f = strings.TrimSpace(*f)
+		if len(*f) > maxFieldLength {
+			return fmt.Errorf("vcard fields are limited to %d characters", maxFieldLength)
+		}
+	}
+	if v.fullName() == "" && v.Organization == "" {
+		return errors.New("a contact needs a name or an organization")
+	}
+	return nil
+}
+
+func (w *WiFi) normalize() error {
+	if w.SSID == "" || len(w.SSID) > 32 {
+		return errors.New("ssid must be 1 to 32 bytes")
+	}
+	switch strings.ToUpper(w.Security) {
+	case "", SecurityWPA:
+		w.Security = SecurityWPA
+		if len(w.Password) < 8 || len(w.Password) > 63 {
+			return errors.New("WPA passwords must be 8 to 63 characters")
+		}
+	case SecurityWEP:
+		w.Security = SecurityWEP
+		if w.Password == "" {
+			return errors.New("WEP networks need a password")
+		}
+	case strings.ToUpper(SecurityNone):
+		w.Security = SecurityNone
+		w.Password = ""
+	default:
+		return errors.New("security must be WPA, WEP or nopass")
+	}
+	return nil
+}
+
+func (e *Event) normalize() error {
+	e.Summary = strings.TrimSpace(e.Summary)
+	if e.Summary == "" {
+		return errors.New("an event needs a summary")
+	}
+	for _, f := range []string{e.Summary, e.Location, e.Description, e.URL} {
+		if len(f) > maxFieldLength {
+			return fmt.Errorf("event fields are limited to %d characters", maxFieldLength)
+		}
+	}
+	if e.Start.IsZero() {
+		return errors.New("an event needs a start time")
+	}
+	if e.AllDay {
+		e.Start = startOfDay(e.Start)
+		if !e.End.IsZero() {
+			e.End = startOfDay(e.End)
+		}
+	}
+	if e.End.IsZero() {
+		if e.AllDay {
+			e.End = e.Start.AddDate(0, 0, 1)
+		} else {
+			e.End = e.Start.Add(time.Hour)
+		}
+	}
+	if e.End.Before(e.Start) {
+		return errors.New("an event cannot end before it starts")
+	}
+	if e.AllDay && !e.End.After(e.Start) {
+		// A one-day event given as a single date.
+		e.End = e.Start.AddDate(0, 0, 1)
+	}
+	return nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
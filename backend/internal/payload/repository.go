@@ -0,0 +1,122 @@
+package payload
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+type Repository interface {
+	Create(p *Payload) (int64, error)
+	GetByID(id int64) (*Payload, error)
+	GetByToken(token string) (*Payload, error)
+	List(userID int64, workspaceID *int64) ([]*Payload, error)
+	Update(p *Payload) error
+	SetURL(id, urlID int64) error
+	Delete(id int64) error
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+// selectPayload joins the dynamic link so its short URL can be shown.
+const selectPayload = `
+	SELECT p.id, p.user_id, p.workspace_id, p.type, p.name, p.content, p.dynamic, p.url_id, p.token,
+	       p.created_at, p.updated_at, COALESCE(u.short_code, ''), COALESCE(d.hostname, '')
+	FROM qr_payloads p
+	LEFT JOIN urls u ON u.id = p.url_id
+	LEFT JOIN domains d ON d.id = u.domain_id`
+
+// ownerScope matches a user's personal payloads ($1, with $2 NULL) or every
+// payload in workspace $2, like the links it mirrors.
+const ownerScope = "(($2::int IS NULL AND p.user_id = $1 AND p.workspace_id IS NULL) OR p.workspace_id = $2)"
+
+func scanPayload(row interface{ Scan(dest ...any) error }) (*Payload, error) {
+	p := &Payload{}
+	var content []byte
+	err := row.Scan(
+		&p.ID, &p.UserID, &p.WorkspaceID, &p.Type, &p.Name, &content, &p.Dynamic, &p.URLID, &p.Token,
+		&p.CreatedAt, &p.UpdatedAt, &p.shortCode, &p.domain,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &p.Content); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *repository) Create(p *Payload) (int64, error) {
+	content, err := json.Marshal(p.Content)
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = r.db.QueryRow(`
+		INSERT INTO qr_payloads (user_id, workspace_id, type, name, content, dynamic, token)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		RETURNING id, created_at, updated_at`,
+		p.UserID, p.WorkspaceID, p.Type, p.Name, string(content), p.Dynamic, p.Token,
+	).Scan(&id, &p.CreatedAt, &p.UpdatedAt)
+	return id, err
+}
+
+func (r *repository) GetByID(id int64) (*Payload, error) {
+	p, err := scanPayload(r.db.QueryRow(selectPayload+" WHERE p.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+func (r *repository) GetByToken(token string) (*Payload, error) {
+	p, err := scanPayload(r.db.QueryRow(selectPayload+" WHERE p.token = $1 AND p.dynamic", token))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+func (r *repository) List(userID int64, workspaceID *int64) ([]*Payload, error) {
+	rows, err := r.db.Query(selectPayload+" WHERE "+ownerScope+" ORDER BY p.created_at DESC", userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Payload
+	for rows.Next() {
+		p, err := scanPayload(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (r *repository) Update(p *Payload) error {
+	content, err := json.Marshal(p.Content)
+	if err != nil {
+		return err
+	}
+	return r.db.QueryRow(
+		"UPDATE qr_payloads SET name=$1, content=$2, updated_at=CURRENT_TIMESTAMP WHERE id=$3 RETURNING updated_at",
+		p.Name, string(content), p.ID,
+	).Scan(&p.UpdatedAt)
+}
+
+func (r *repository) SetURL(id, urlID int64) error {
+	_, err := r.db.Exec("UPDATE qr_payloads SET url_id=$1 WHERE id=$2", urlID, id)
+	return err
+}
+
+func (r *repository) Delete(id int64) error {
+	_, err := r.db.Exec("DELETE FROM qr_payloads WHERE id=$1", id)
+	return err
+}
@@ -0,0 +1,350 @@
+package payload
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+	"url-shortener/internal/qr"
+	"url-shortener/internal/url"
+	"url-shortener/internal/workspace"
+)
+
+var ErrPayloadNotFound = errors.New("QR payload not found")
+
+// Input creates or updates a payload. Type, Dynamic and the link settings
+// only apply on create.
+type Input struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Dynamic     bool   `json:"dynamic"`
+	WorkspaceID *int64 `json:"workspace_id"`
+	DomainID    *int64 `json:"domain_id"`
+	Alias       string `json:"alias"`
+	Content
+}
+
+type Service interface {
+	Create(userID int64, in Input) (*Payload, error)
+	Get(userID, id int64) (*Payload, error)
+	List(userID int64, workspaceID *int64) ([]*Payload, error)
+	Update(userID, id int64, in Input) (*Payload, error)
+	Delete(userID, id int64) error
+	// RenderQR draws the payload's QR code, returning the file and its
+	// download name.
+	RenderQR(userID, id int64, opts qr.Options) (*qr.Output, string, error)
+	// File serves a dynamic payload's content to whoever followed its link.
+	File(token string) (*File, error)
+}
+
+type service struct {
+	repo             Repository
+	urlService       url.Service
+	workspaceService workspace.Service
+	qrRenderer       *qr.Renderer
+}
+
+func NewService(repo Repository, urlService url.Service, workspaceService workspace.Service, qrRenderer *qr.Renderer) Service {
+	return &service{
+		repo:             repo,
+		urlService:       urlService,
+		workspaceService: workspaceService,
+		qrRenderer:       qrRenderer,
+	}
+}
+
+func (s *service) Create(userID int64, in Input) (*Payload, error) {
+	if err := s.authorizeScope(userID, in.WorkspaceID, workspace.PermEdit); err != nil {
+		return nil, err
+	}
+
+	p := &Payload{
+		UserID:      userID,
+		WorkspaceID: in.WorkspaceID,
+		Type:        in.Type,
+		Dynamic:     in.Dynamic,
+	}
+	if err := s.apply(p, in); err != nil {
+		return nil, err
+	}
+	if p.Dynamic && p.Type == TypeWiFi {
+		return nil, errors.New("wifi payloads cannot be dynamic: phones only join networks from the code itself")
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	p.Token = token
+
+	id, err := s.repo.Create(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create QR payload: %w", err)
+	}
+	p.ID = id
+
+	if p.Dynamic {
+		u, err := s.urlService.CreateShortURL(userID, url.CreateURLInput{
+			OriginalURL: fileURL(p),
+			WorkspaceID: in.WorkspaceID,
+			DomainID:    in.DomainID,
+			Alias:       in.Alias,
+		})
+		if err != nil {
+			_ = s.repo.Delete(id)
+			return nil, err
+		}
+		if err := s.repo.SetURL(id, u.ID); err != nil {
+			if err := s.urlService.DiscardURL(userID, u.ID); err != nil {
+				log.Printf("❌ Failed to remove link %d of unlinked QR payload: %v", u.ID, err)
+			}
+			_ = s.repo.Delete(id)
+			return nil, fmt.Errorf("failed to link QR payload: %w", err)
+		}
+		p.URLID = &u.ID
+		p.shortCode, p.domain = u.ShortCode, u.Domain
+	}
+
+	s.decorate(p)
+	return p, nil
+}
+
+// apply validates the name and content of in onto p.
+func (s *service) apply(p *Payload, in Input) error {
+	if err := in.Content.normalize(p.Type); err != nil {
+		return err
+	}
+	p.Content = in.Content
+
+	p.Name = strings.TrimSpace(in.Name)
+	if p.Name == "" {
+		p.Name = p.Content.defaultName()
+	}
+	if len(p.Name) > MaxNameLength {
+		return fmt.Errorf("name too long (max %d characters)", MaxNameLength)
+	}
+
+	// A static code must hold the whole content.
+	if !p.Dynamic {
+		if _, err := qr.Modules(p.qrContent(), qr.DefaultStyle()); err != nil {
+			return errors.New("content is too long for a QR code; shorten it or make the payload dynamic")
+		}
+	}
+	return nil
+}
+
+func (s *service) Get(userID, id int64) (*Payload, error) {
+	p, err := s.getAuthorized(userID, id, workspace.PermView)
+	if err != nil {
+		return nil, err
+	}
+	s.decorate(p)
+	return p, nil
+}
+
+func (s *service) List(userID int64, workspaceID *int64) ([]*Payload, error) {
+	if err := s.authorizeScope(userID, workspaceID, workspace.PermView); err != nil {
+		return nil, err
+	}
+	list, err := s.repo.List(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range list {
+		s.decorate(p)
+	}
+	return list, nil
+}
+
+// Update changes the content. A dynamic payload keeps its short link, so
+// printed codes pick up the change.
+func (s *service) Update(userID, id int64, in Input) (*Payload, error) {
+	p, err := s.getAuthorized(userID, id, workspace.PermEdit)
+	if err != nil {
+		return nil, err
+	}
+	if in.Type != "" && in.Type != p.Type {
+		return nil, errors.New("the type of a payload cannot change")
+	}
+	if err := s.apply(p, in); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(p); err != nil {
+		return nil, fmt.Errorf("failed to update QR payload: %w", err)
+	}
+	s.decorate(p)
+	return p, nil
+}
+
+// Delete removes the payload along with its short link.
+func (s *service) Delete(userID, id int64) error {
+	p, err := s.getAuthorized(userID, id, workspace.PermEdit)
+	if err != nil {
+		return err
+	}
+	if p.URLID != nil {
+		if err := s.urlService.DeleteURL(userID, *p.URLID); err != nil && !errors.Is(err, url.ErrURLNotFound) {
+			return err
+		}
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *service) RenderQR(userID, id int64, opts qr.Options) (*qr.Output, string, error) {
+	p, err := s.getAuthorized(userID, id, workspace.PermView)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if p.Dynamic {
+		if p.URLID == nil {
+			return nil, "", errors.New("the short link of this payload was removed")
+		}
+		out, _, err := s.urlService.RenderQR(userID, *p.URLID, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return out, fileName(p.Name) + "." + out.Extension, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	out, err := s.qrRenderer.Render(ctx, p.qrContent(), qr.DefaultStyle(), opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return out, fileName(p.Name) + "." + out.Extension, nil
+}
+
+func (s *service) File(token string) (*File, error) {
+	p, err := s.repo.GetByToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load QR payload: %w", err)
+	}
+	if p == nil {
+		return nil, ErrPayloadNotFound
+	}
+
+	switch p.Type {
+	case TypeVCard:
+		return &File{
+			Name:        fileName(p.Name) + ".vcf",
+			Data:        []byte(p.VCard.encode(true)),
+			ContentType: "text/vcard; charset=utf-8",
+		}, nil
+	case TypeEvent:
+		uid := "payload-" + strconv.FormatInt(p.ID, 10) + "@" + publicHost()
+		return &File{
+			Name:        fileName(p.Name) + ".ics",
+			Data:        []byte(p.Event.encode(true, uid, p.UpdatedAt)),
+			ContentType: "text/calendar; charset=utf-8",
+		}, nil
+	}
+	return nil, ErrPayloadNotFound
+}
+
+// qrContent is what a static payload's code encodes; dynamic ones encode
+// their short link instead.
+func (p *Payload) qrContent() string {
+	switch {
+	case p.VCard != nil:
+		return p.VCard.encode(false)
+	case p.WiFi != nil:
+		return p.WiFi.encode()
+	case p.Event != nil:
+		return p.Event.encode(false, "", time.Time{})
+	}
+	return ""
+}
+
+// decorate fills in the URLs shown to clients.
+func (s *service) decorate(p *Payload) {
+	if !p.Dynamic {
+		return
+	}
+	p.FileURL = fileURL(p)
+	if p.shortCode != "" {
+		p.ShortURL = url.BuildShortURL(p.domain, p.shortCode)
+		p.QRURL = url.BuildQRURL(p.domain, p.shortCode)
+	}
+}
+
+func (s *service) authorizeScope(userID int64, workspaceID *int64, perm workspace.Permission) error {
+	if workspaceID == nil {
+		return nil
+	}
+	_, err := s.workspaceService.Authorize(userID, *workspaceID, perm)
+	return err
+}
+
+func (s *service) getAuthorized(userID, id int64, perm workspace.Permission) (*Payload, error) {
+	p, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load QR payload: %w", err)
+	}
+	if p == nil {
+		return nil, ErrPayloadNotFound
+	}
+	if p.WorkspaceID == nil {
+		if p.UserID != userID {
+			return nil, ErrPayloadNotFound
+		}
+		return p, nil
+	}
+	if err := s.authorizeScope(userID, p.WorkspaceID, perm); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// fileURL is the public endpoint serving a dynamic payload's file.
+func fileURL(p *Payload) string {
+	ext := ".vcf"
+	if p.Type == TypeEvent {
+		ext = ".ics"
+	}
+	return url.PublicAPIURL() + "/api/payloads/files/" + p.Token + ext
+}
+
+func publicHost() string {
+	host := strings.TrimPrefix(strings.TrimPrefix(url.PublicAPIURL(), "https://"), "http://")
+	if i := strings.IndexAny(host, ":/"); i >= 0 {
+		host = host[:i]
+	}
+	return host
+}
+
+// fileName makes a payload name safe for Content-Disposition, keeping
+// ASCII letters and digits.
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) || r == '-' || r == '_' {
+			return r
+		}
+		if r == ' ' || r == '.' {
+			return '_'
+		}
+		return -1
+	}, name)
+	if name == "" {
+		return "qr"
+	}
+	return name
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("❌ Failed to generate payload token: %v", err)
+		return "", errors.New("failed to create QR payload")
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,68 @@
+package qr
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is a size-bounded LRU of rendered QR codes, keyed by everything that
+// affects the output.
+type Cache struct {
+	mu       sync.Mutex
+	maxBytes int
+	bytes    int
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key string
+	out *Output
+}
+
+// NewCache keeps up to maxBytes of rendered output. A zero size disables
+// caching.
+func NewCache(maxBytes int) *Cache {
+	return &Cache{maxBytes: maxBytes, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *Cache) Get(key string) (*Output, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheEntry).out, true
+}
+
+func (c *Cache) Add(key string, out *Output) {
+	if c == nil || len(out.Data) > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.bytes -= len(el.Value.(*cacheEntry).out.Data)
+		el.Value.(*cacheEntry).out = out
+		c.bytes += len(out.Data)
+		c.order.MoveToFront(el)
+	} else {
+		c.items[key] = c.order.PushFront(&cacheEntry{key: key, out: out})
+		c.bytes += len(out.Data)
+	}
+
+	for c.bytes > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*cacheEntry)
+		c.order.Remove(oldest)
+		delete(c.items, entry.key)
+		c.bytes -= len(entry.out.Data)
+	}
+}
@@ -0,0 +1,150 @@
+package qr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+	"url-shortener/internal/utils"
+)
+
+const (
+	maxLogoBytes = 2 << 20
+	// maxLogoDimension bounds the decoded image: a small file can declare
+	// an enormous one.
+	maxLogoDimension = 4096
+)
+
+// Fetched logos are kept, shrunk to logoRasterSize, so renders that miss
+// the output cache do not download them again. Failures are kept briefly
+// so a broken URL is not retried on every render.
+const (
+	maxCachedLogos = 64
+	logoCacheTTL   = time.Hour
+	logoFailureTTL = time.Minute
+)
+
+var ErrLogoUnavailable = errors.New("QR logo could not be loaded")
+
+// LogoFetcher loads the image behind a style's LogoURL.
+type LogoFetcher interface {
+	Fetch(ctx context.Context, url string) (image.Image, error)
+}
+
+type httpLogoFetcher struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedLogo
+}
+
+type cachedLogo struct {
+	img     image.Image
+	err     error
+	expires time.Time
+}
+
+// NewHTTPLogoFetcher downloads logos over HTTP. Logo URLs are user input, so
+// connections to loopback, private and link-local addresses are refused.
+func NewHTTPLogoFetcher(timeout time.Duration) LogoFetcher {
+	dialer := utils.PublicDialer(timeout)
+	return &httpLogoFetcher{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+		},
+		cache: make(map[string]cachedLogo),
+	}
+}
+
+func (f *httpLogoFetcher) Fetch(ctx context.Context, url string) (image.Image, error) {
+	now := time.Now()
+	f.mu.Lock()
+	entry, ok := f.cache[url]
+	f.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.img, entry.err
+	}
+
+	img, err := f.download(ctx, url)
+	if ctx.Err() != nil {
+		// The caller gave up; that says nothing about the logo.
+		return nil, err
+	}
+	entry = cachedLogo{img: img, err: err, expires: now.Add(logoCacheTTL)}
+	if err != nil {
+		entry.expires = now.Add(logoFailureTTL)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.cache) >= maxCachedLogos {
+		for key, e := range f.cache {
+			if now.After(e.expires) {
+				delete(f.cache, key)
+			}
+		}
+		// Still full: drop any entry, which is as good as any for a cache
+		// this small.
+		for key := range f.cache {
+			if len(f.cache) < maxCachedLogos {
+				break
+			}
+			delete(f.cache, key)
+		}
+	}
+	f.cache[url] = entry
+	return img, err
+}
+
+func (f *httpLogoFetcher) download(ctx context.Context, url string) (image.Image, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLogoUnavailable, err)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLogoUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrLogoUnavailable, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLogoBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLogoUnavailable, err)
+	}
+	if len(data) > maxLogoBytes {
+		return nil, fmt.Errorf("%w: larger than %d bytes", ErrLogoUnavailable, maxLogoBytes)
+	}
+	return decodeLogo(data)
+}
+
+// decodeLogo checks the declared dimensions before decoding, then shrinks
+// the image to what any rendering needs.
+func decodeLogo(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLogoUnavailable, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxLogoDimension || cfg.Height > maxLogoDimension {
+		return nil, fmt.Errorf("%w: %dx%d pixels (max %d)", ErrLogoUnavailable, cfg.Width, cfg.Height, maxLogoDimension)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLogoUnavailable, err)
+	}
+	if b := img.Bounds(); b.Dx() > logoRasterSize || b.Dy() > logoRasterSize {
+		img = scaleToFit(img, logoRasterSize)
+	}
+	return img, nil
+}
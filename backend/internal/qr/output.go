@@ -0,0 +1,170 @@
+package qr
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+)
+
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+	FormatPDF = "pdf"
+	FormatEPS = "eps"
+)
+
+const (
+	MinDPI     = 72
+	MaxDPI     = 2400
+	MaxWidthMM = 5000
+)
+
+// RasterSizes are the pixel sizes of PNGs sized from DPI and width. The
+// requested size is rounded up to one of them, so large or endlessly
+// varied sizes cannot be used to exhaust memory or the cache; bigger prints
+// should use a vector format.
+var RasterSizes = []int{256, 512, 1024, MaxSize}
+
+// Options selects the output format. WidthMM gives the printed size; DPI
+// only applies to PNG, where it sets the pixel size (with WidthMM, rounded
+// up to one of RasterSizes) and the resolution recorded in the file, which
+// is adjusted so the printed width stays WidthMM.
+type Options struct {
+	Format  string
+	DPI     int
+	WidthMM float64
+}
+
+type Output struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+	// ETag identifies the inputs the output was rendered from.
+	ETag string
+}
+
+// Validate fills in the default format and checks the options.
+func (o *Options) Validate() error {
+	if o.Format == "" {
+		o.Format = FormatPNG
+	}
+	switch o.Format {
+	case FormatPNG, FormatSVG, FormatPDF, FormatEPS:
+	default:
+		return errors.New("format must be one of png, svg, pdf, eps")
+	}
+	if o.DPI != 0 && (o.DPI < MinDPI || o.DPI > MaxDPI) {
+		return fmt.Errorf("dpi must be between %d and %d", MinDPI, MaxDPI)
+	}
+	if o.WidthMM < 0 || o.WidthMM > MaxWidthMM {
+		return fmt.Errorf("width_mm must be between 0 and %d", MaxWidthMM)
+	}
+	return nil
+}
+
+// Render draws content in the given style and format. Identical requests are
+// served from the cache.
+func (r *Renderer) Render(ctx context.Context, content string, style Style, opts Options) (*Output, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	key := cacheKey(content, style, opts)
+	if out, ok := r.cache.Get(key); ok {
+		return out, nil
+	}
+	out, err := r.render(ctx, content, style, opts)
+	if err != nil {
+		return nil, err
+	}
+	out.ETag = `"` + key[:32] + `"`
+	r.cache.Add(key, out)
+	return out, nil
+}
+
+// cacheKey hashes every input that changes the rendered bytes.
+func cacheKey(content string, style Style, opts Options) string {
+	raw, _ := json.Marshal(struct {
+		Content string
+		Style   Style
+		Options Options
+	}{content, style, opts})
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *Renderer) render(ctx context.Context, content string, style Style, opts Options) (*Output, error) {
+	switch opts.Format {
+	case FormatSVG:
+		data, err := r.SVG(ctx, content, style, opts.WidthMM)
+		return &Output{Data: data, ContentType: "image/svg+xml", Extension: "svg"}, err
+	case FormatPDF:
+		data, err := r.PDF(ctx, content, style, opts.WidthMM)
+		return &Output{Data: data, ContentType: "application/pdf", Extension: "pdf"}, err
+	case FormatEPS:
+		data, err := r.EPS(ctx, content, style, opts.WidthMM)
+		return &Output{Data: data, ContentType: "application/postscript", Extension: "eps"}, err
+	}
+
+	if opts.DPI > 0 && opts.WidthMM > 0 {
+		inches := opts.WidthMM / 25.4
+		size, ok := rasterSize(inches * float64(opts.DPI))
+		if !ok {
+			return nil, fmt.Errorf("width_mm at %d dpi needs more than %dpx; use svg, pdf or eps for large prints",
+				opts.DPI, RasterSizes[len(RasterSizes)-1])
+		}
+		style.Size = size
+		opts.DPI = int(math.Round(float64(size) / inches))
+	}
+	data, err := r.PNG(ctx, content, style)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DPI > 0 {
+		data = withDPI(data, opts.DPI)
+	}
+	return &Output{Data: data, ContentType: "image/png", Extension: "png"}, nil
+}
+
+// rasterSize rounds px up to the nearest of RasterSizes.
+func rasterSize(px float64) (int, bool) {
+	for _, size := range RasterSizes {
+		if px <= float64(size) {
+			return size, true
+		}
+	}
+	return 0, false
+}
+
+// withDPI inserts a pHYs chunk right after IHDR so print software picks up
+// the intended resolution. image/png never writes one itself.
+func withDPI(data []byte, dpi int) []byte {
+	// 8-byte signature, then IHDR: 4 length + 4 type + 13 data + 4 CRC.
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4
+	if len(data) < ihdrEnd {
+		return data
+	}
+
+	ppm := uint32(math.Round(float64(dpi) / 0.0254))
+	chunk := make([]byte, 4+4+9+4)
+	binary.BigEndian.PutUint32(chunk[0:], 9)
+	copy(chunk[4:], "pHYs")
+	binary.BigEndian.PutUint32(chunk[8:], ppm)
+	binary.BigEndian.PutUint32(chunk[12:], ppm)
+	chunk[16] = 1 // unit: metre
+	binary.BigEndian.PutUint32(chunk[17:], crc32.ChecksumIEEE(chunk[4:17]))
+
+	var out bytes.Buffer
+	out.Grow(len(data) + len(chunk))
+	out.Write(data[:ihdrEnd])
+	out.Write(chunk)
+	out.Write(data[ihdrEnd:])
+	return out.Bytes()
+}
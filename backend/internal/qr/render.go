@@ -0,0 +1,152 @@
+package qr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// logoScale is the logo's share of the code's width. At level H up to 30% of
+// codewords can be lost, and a 22% square stays well inside that.
+const logoScale = 0.22
+
+// Renderer draws styled QR codes, fetching logos as needed. Render results
+// are kept in cache, which may be nil.
+type Renderer struct {
+	logos LogoFetcher
+	cache *Cache
+}
+
+func NewRenderer(logos LogoFetcher, cache *Cache) *Renderer {
+	return &Renderer{logos: logos, cache: cache}
+}
+
+// PNG renders content as a PNG image in the given style. The style must
+// already be normalized.
+func (r *Renderer) PNG(ctx context.Context, content string, style Style) ([]byte, error) {
+	img, err := r.Image(ctx, content, style)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Image renders content as an image in the given style.
+func (r *Renderer) Image(ctx context.Context, content string, style Style) (image.Image, error) {
+	modules, err := Modules(content, style)
+	if err != nil {
+		return nil, err
+	}
+	fg, _ := parseHexColor(style.Foreground)
+	bg, _ := parseHexColor(style.Background)
+
+	img := image.NewRGBA(image.Rect(0, 0, style.Size, style.Size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
+
+	// Modules are mapped to whole pixel ranges so edges stay crisp at any
+	// size; the quiet zone absorbs the rounding.
+	n := len(modules) + 2*style.QuietZone
+	for y := 0; y < style.Size; y++ {
+		my := y*n/style.Size - style.QuietZone
+		if my < 0 || my >= len(modules) {
+			continue
+		}
+		for x := 0; x < style.Size; x++ {
+			mx := x*n/style.Size - style.QuietZone
+			if mx >= 0 && mx < len(modules) && modules[my][mx] {
+				img.SetRGBA(x, y, fg)
+			}
+		}
+	}
+
+	if style.LogoURL != "" {
+		logo, err := r.logos.Fetch(ctx, style.LogoURL)
+		if err != nil {
+			return nil, err
+		}
+		drawLogo(img, logo, bg, float64(style.Size)*float64(len(modules))/float64(n))
+	}
+	return img, nil
+}
+
+// Modules returns the code's module matrix without a border; true is a dark
+// module.
+func Modules(content string, style Style) ([][]bool, error) {
+	code, err := qrcode.New(content, style.level())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+	code.DisableBorder = true
+	return code.Bitmap(), nil
+}
+
+// drawLogo centres logo on img over a background-colored pad. codeWidth is
+// the width of the symbol itself in pixels, excluding the quiet zone.
+func drawLogo(img *image.RGBA, logo image.Image, bg color.RGBA, codeWidth float64) {
+	box := int(codeWidth * logoScale)
+	if box < 8 {
+		return
+	}
+	pad := box / 10
+	size := img.Bounds().Dx()
+
+	padRect := image.Rect((size-box)/2-pad, (size-box)/2-pad, (size+box)/2+pad, (size+box)/2+pad)
+	draw.Draw(img, padRect, &image.Uniform{bg}, image.Point{}, draw.Src)
+
+	scaled := scaleToFit(logo, box)
+	b := scaled.Bounds()
+	at := image.Pt((size-b.Dx())/2, (size-b.Dy())/2)
+	draw.Draw(img, b.Add(at), scaled, b.Min, draw.Over)
+}
+
+// scaleToFit resizes src to fit a box×box square, keeping its aspect ratio.
+// Each destination pixel averages the source pixels it covers, which is
+// plenty for shrinking logos.
+func scaleToFit(src image.Image, box int) *image.RGBA {
+	sb := src.Bounds()
+	w, h := box, box
+	if sb.Dx() > sb.Dy() {
+		h = box * sb.Dy() / sb.Dx()
+	} else {
+		w = box * sb.Dx() / sb.Dy()
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := sb.Min.Y + y*sb.Dy()/h
+		y1 := max(sb.Min.Y+(y+1)*sb.Dy()/h, y0+1)
+		for x := 0; x < w; x++ {
+			x0 := sb.Min.X + x*sb.Dx()/w
+			x1 := max(sb.Min.X+(x+1)*sb.Dx()/w, x0+1)
+
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					count++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / count), G: uint16(g / count), B: uint16(b / count), A: uint16(a / count),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,165 @@
+package qr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A4 in points, with a margin wide enough for most office printers.
+const (
+	sheetWidth   = 595.276
+	sheetHeight  = 841.890
+	sheetMargin  = 36.0
+	sheetPadding = 8.0
+
+	labelSize   = 10.0
+	captionSize = 7.0
+	// textBlock is the height reserved under each code for both lines.
+	textBlock = labelSize + captionSize + 8
+
+	MinSheetColumns = 1
+	MaxSheetColumns = 6
+)
+
+// SheetItem is one code on a printable sheet.
+type SheetItem struct {
+	Content string
+	Style   Style
+	// Label and Caption are printed under the code, e.g. the alias and the
+	// short URL.
+	Label   string
+	Caption string
+}
+
+// Sheet lays codes out on A4 pages in a grid of the given number of columns,
+// each with its label and caption underneath, for printing and cutting.
+func (r *Renderer) Sheet(ctx context.Context, items []SheetItem, columns int) ([]byte, error) {
+	if len(items) == 0 {
+		return nil, errors.New("nothing to print")
+	}
+	if columns < MinSheetColumns || columns > MaxSheetColumns {
+		return nil, fmt.Errorf("columns must be between %d and %d", MinSheetColumns, MaxSheetColumns)
+	}
+
+	cellW := (sheetWidth - 2*sheetMargin) / float64(columns)
+	code := cellW - 2*sheetPadding
+	cellH := code + 2*sheetPadding + textBlock
+	rows := int((sheetHeight - 2*sheetMargin) / cellH)
+	if rows < 1 {
+		rows = 1
+	}
+	perPage := rows * columns
+
+	// Objects 1 to 3 are the catalog, the page tree and the font; pages,
+	// their contents and logos follow.
+	objects := []string{"", "", "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"}
+	add := func(obj string) int {
+		objects = append(objects, obj)
+		return len(objects)
+	}
+
+	var kids []string
+	for start := 0; start < len(items); start += perPage {
+		end := min(start+perPage, len(items))
+
+		var cs bytes.Buffer
+		var xobjects strings.Builder
+		for i, item := range items[start:end] {
+			l, err := r.layout(ctx, item.Content, item.Style)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render %s: %w", item.Label, err)
+			}
+
+			col, row := i%columns, i/columns
+			left := sheetMargin + float64(col)*cellW
+			top := sheetHeight - sheetMargin - float64(row)*cellH
+			x, y := left+sheetPadding, top-sheetPadding-code
+
+			logo := fmt.Sprintf("Logo%d", i)
+			if l.logo != nil {
+				obj, err := l.pdfLogo()
+				if err != nil {
+					return nil, err
+				}
+				fmt.Fprintf(&xobjects, " /%s %d 0 R", logo, add(obj))
+			}
+			l.pdfDraw(&cs, x, y, code, logo)
+
+			center := left + cellW/2
+			pdfText(&cs, item.Label, labelSize, center, y-labelSize-2, code)
+			pdfText(&cs, item.Caption, captionSize, center, y-labelSize-captionSize-6, code)
+		}
+
+		resources := "<< /Font << /F1 3 0 R >>"
+		if xobjects.Len() > 0 {
+			resources += " /XObject <<" + xobjects.String() + " >>"
+		}
+		resources += " >>"
+
+		contents := add(pdfStream(cs.Bytes()))
+		page := add(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.3f %.3f] /Resources %s /Contents %d 0 R >>",
+			sheetWidth, sheetHeight, resources, contents))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+	return writePDF(objects), nil
+}
+
+// pdfText draws black text centred on x with its baseline at y, shortened
+// with an ellipsis to fit maxWidth.
+func pdfText(cs *bytes.Buffer, text string, size, x, y, maxWidth float64) {
+	text = winAnsi(text)
+	if text == "" {
+		return
+	}
+	if textWidth(text, size) > maxWidth {
+		for len(text) > 0 && textWidth(text+"...", size) > maxWidth {
+			text = text[:len(text)-1]
+		}
+		text += "..."
+	}
+	fmt.Fprintf(cs, "0 0 0 rg BT /F1 %.1f Tf %.3f %.3f Td (%s) Tj ET\n",
+		size, x-textWidth(text, size)/2, y, pdfEscape(text))
+}
+
+// winAnsi keeps the printable ASCII subset of s, which the standard fonts
+// render without embedding; anything else becomes '?'.
+func winAnsi(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 32 || r > 126 {
+			r = '?'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func pdfEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`).Replace(s)
+}
+
+// textWidth measures s in Helvetica at the given size.
+func textWidth(s string, size float64) float64 {
+	w := 0
+	for i := 0; i < len(s); i++ {
+		w += helveticaWidths[s[i]-32]
+	}
+	return float64(w) * size / 1000
+}
+
+// helveticaWidths are the glyph widths of Helvetica for ASCII 32 to 126, in
+// thousandths of the font size, from the standard AFM metrics.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
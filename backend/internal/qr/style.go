@@ -0,0 +1,170 @@
+package qr
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	LevelLow      = "L"
+	LevelMedium   = "M"
+	LevelQuartile = "Q"
+	LevelHigh     = "H"
+)
+
+const (
+	MinSize          = 64
+	MaxSize          = 2048
+	MaxQuietZone     = 16
+	minContrast      = 3.0
+	defaultQuietZone = 4
+)
+
+// Style controls how a link's QR code is drawn. It is stored per link as
+// JSON.
+type Style struct {
+	// Size is the image width and height in pixels.
+	Size int `json:"size"`
+	// ErrorCorrection is one of L, M, Q, H. A logo forces H.
+	ErrorCorrection string `json:"error_correction"`
+	// QuietZone is the blank border in modules; scanners expect 4.
+	QuietZone  int    `json:"quiet_zone"`
+	Foreground string `json:"foreground"`
+	Background string `json:"background"`
+	// LogoURL is an optional PNG, JPEG or GIF drawn over the centre.
+	LogoURL string `json:"logo_url,omitempty"`
+}
+
+func DefaultStyle() Style {
+	return Style{
+		Size:            256,
+		ErrorCorrection: LevelMedium,
+		QuietZone:       defaultQuietZone,
+		Foreground:      "#000000",
+		Background:      "#ffffff",
+	}
+}
+
+// Normalize validates the style and canonicalizes colors and the error
+// correction level.
+func (s *Style) Normalize() error {
+	if s.Size < MinSize || s.Size > MaxSize {
+		return fmt.Errorf("QR size must be between %d and %d pixels", MinSize, MaxSize)
+	}
+	if s.QuietZone < 0 || s.QuietZone > MaxQuietZone {
+		return fmt.Errorf("QR quiet zone must be between 0 and %d modules", MaxQuietZone)
+	}
+
+	s.ErrorCorrection = strings.ToUpper(strings.TrimSpace(s.ErrorCorrection))
+	if _, ok := levels[s.ErrorCorrection]; !ok {
+		return errors.New("QR error correction must be one of L, M, Q, H")
+	}
+
+	fg, err := parseHexColor(s.Foreground)
+	if err != nil {
+		return fmt.Errorf("invalid QR foreground: %w", err)
+	}
+	bg, err := parseHexColor(s.Background)
+	if err != nil {
+		return fmt.Errorf("invalid QR background: %w", err)
+	}
+	if contrast(fg, bg) < minContrast {
+		return errors.New("QR foreground and background need more contrast to scan reliably")
+	}
+	s.Foreground = formatHexColor(fg)
+	s.Background = formatHexColor(bg)
+
+	s.LogoURL = strings.TrimSpace(s.LogoURL)
+	if s.LogoURL != "" {
+		if !strings.HasPrefix(s.LogoURL, "https://") && !strings.HasPrefix(s.LogoURL, "http://") {
+			return errors.New("QR logo_url must be an http(s) URL")
+		}
+		// The logo hides modules in the middle of the code, which only
+		// the highest level can recover.
+		s.ErrorCorrection = LevelHigh
+	}
+	return nil
+}
+
+func (s Style) level() qrcode.RecoveryLevel {
+	return levels[s.ErrorCorrection]
+}
+
+var levels = map[string]qrcode.RecoveryLevel{
+	LevelLow:      qrcode.Low,
+	LevelMedium:   qrcode.Medium,
+	LevelQuartile: qrcode.High,
+	LevelHigh:     qrcode.Highest,
+}
+
+func (s *Style) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*s = DefaultStyle()
+		return nil
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return fmt.Errorf("cannot scan %T into Style", src)
+	}
+	*s = DefaultStyle()
+	return json.Unmarshal(raw, s)
+}
+
+func (s Style) Value() (driver.Value, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func parseHexColor(value string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, errors.New("color must be #rgb or #rrggbb")
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, errors.New("color must be #rgb or #rrggbb")
+	}
+	return color.RGBA{R: uint8(n >> 16), G: uint8(n >> 8), B: uint8(n), A: 0xff}, nil
+}
+
+func formatHexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// contrast is the WCAG contrast ratio between two colors.
+func contrast(a, b color.RGBA) float64 {
+	la, lb := luminance(a), luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+func luminance(c color.RGBA) float64 {
+	channel := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
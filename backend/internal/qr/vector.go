@@ -0,0 +1,321 @@
+package qr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+)
+
+// logoRasterSize is the resolution logos are embedded at in vector output,
+// enough for print at several centimetres.
+const logoRasterSize = 512
+
+// layout is a QR code in module units, shared by the vector writers. The
+// quiet zone is included, so the symbol spans [0, size) in both axes.
+type layout struct {
+	modules [][]bool
+	quiet   int
+	size    int
+	fg, bg  color.RGBA
+	// logo, when set, is drawn into logoBox over a background pad.
+	logo    image.Image
+	padBox  [4]float64
+	logoBox [4]float64
+}
+
+// run is a horizontal stretch of dark modules.
+type run struct {
+	x, y, w int
+}
+
+func (r *Renderer) layout(ctx context.Context, content string, style Style) (*layout, error) {
+	modules, err := Modules(content, style)
+	if err != nil {
+		return nil, err
+	}
+	l := &layout{modules: modules, quiet: style.QuietZone, size: len(modules) + 2*style.QuietZone}
+	l.fg, _ = parseHexColor(style.Foreground)
+	l.bg, _ = parseHexColor(style.Background)
+
+	if style.LogoURL != "" {
+		logo, err := r.logos.Fetch(ctx, style.LogoURL)
+		if err != nil {
+			return nil, err
+		}
+		l.logo = scaleToFit(logo, logoRasterSize)
+
+		box := float64(len(modules)) * logoScale
+		pad := box / 10
+		center := float64(l.size) / 2
+		l.padBox = [4]float64{center - box/2 - pad, center - box/2 - pad, box + 2*pad, box + 2*pad}
+
+		b := l.logo.Bounds()
+		w, h := box, box
+		if b.Dx() > b.Dy() {
+			h = box * float64(b.Dy()) / float64(b.Dx())
+		} else {
+			w = box * float64(b.Dx()) / float64(b.Dy())
+		}
+		l.logoBox = [4]float64{center - w/2, center - h/2, w, h}
+	}
+	return l, nil
+}
+
+// runs merges adjacent dark modules so each row needs few shapes.
+func (l *layout) runs() []run {
+	var runs []run
+	for y, row := range l.modules {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			runs = append(runs, run{x: start + l.quiet, y: y + l.quiet, w: x - start})
+		}
+	}
+	return runs
+}
+
+// flatLogo composites the logo over the background, for formats without
+// alpha support.
+func (l *layout) flatLogo() *image.RGBA {
+	b := l.logo.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(img, img.Bounds(), &image.Uniform{l.bg}, image.Point{}, draw.Src)
+	draw.Draw(img, img.Bounds(), l.logo, b.Min, draw.Over)
+	return img
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// SVG renders the code as an SVG document. widthMM sets the physical size;
+// zero uses the style's pixel size.
+func (r *Renderer) SVG(ctx context.Context, content string, style Style, widthMM float64) ([]byte, error) {
+	l, err := r.layout(ctx, content, style)
+	if err != nil {
+		return nil, err
+	}
+
+	dim := fmt.Sprintf("%d", style.Size)
+	if widthMM > 0 {
+		dim = fmt.Sprintf("%gmm", widthMM)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%s" height="%s" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		dim, dim, l.size, l.size)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`+"\n", l.size, l.size, svgColor(l.bg))
+
+	var path strings.Builder
+	for _, rn := range l.runs() {
+		fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", rn.x, rn.y, rn.w, rn.w)
+	}
+	fmt.Fprintf(&b, `<path fill="%s" d="%s"/>`+"\n", svgColor(l.fg), path.String())
+
+	if l.logo != nil {
+		var logo bytes.Buffer
+		if err := png.Encode(&logo, l.logo); err != nil {
+			return nil, fmt.Errorf("failed to encode QR logo: %w", err)
+		}
+		fmt.Fprintf(&b, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s"/>`+"\n",
+			l.padBox[0], l.padBox[1], l.padBox[2], l.padBox[3], svgColor(l.bg))
+		fmt.Fprintf(&b, `<image x="%g" y="%g" width="%g" height="%g" xlink:href="data:image/png;base64,%s"/>`+"\n",
+			l.logoBox[0], l.logoBox[1], l.logoBox[2], l.logoBox[3], base64.StdEncoding.EncodeToString(logo.Bytes()))
+	}
+
+	b.WriteString("</svg>\n")
+	return b.Bytes(), nil
+}
+
+// points converts the requested physical width to PostScript points,
+// defaulting to the style's pixel size at 96 DPI.
+func points(style Style, widthMM float64) float64 {
+	if widthMM > 0 {
+		return widthMM / 25.4 * 72
+	}
+	return float64(style.Size) * 72 / 96
+}
+
+func pdfColor(c color.RGBA) string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// PDF renders the code as a single-page PDF sized to the code.
+func (r *Renderer) PDF(ctx context.Context, content string, style Style, widthMM float64) ([]byte, error) {
+	l, err := r.layout(ctx, content, style)
+	if err != nil {
+		return nil, err
+	}
+	page := points(style, widthMM)
+
+	var cs bytes.Buffer
+	l.pdfDraw(&cs, 0, 0, page, "Logo")
+	stream := cs.Bytes()
+
+	resources := "<< >>"
+	if l.logo != nil {
+		resources = "<< /XObject << /Logo 5 0 R >> >>"
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.3f %.3f] /Resources %s /Contents 4 0 R >>",
+			page, page, resources),
+		pdfStream(stream),
+	}
+	if l.logo != nil {
+		logo, err := l.pdfLogo()
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, logo)
+	}
+	return writePDF(objects), nil
+}
+
+// pdfDraw appends the operators that draw the code into a size×size square
+// with its bottom-left corner at (x, y). PDF's origin is bottom-left, so
+// module rows are flipped. logo names the logo's image XObject.
+func (l *layout) pdfDraw(cs *bytes.Buffer, x, y, size float64, logo string) {
+	unit := size / float64(l.size)
+	fmt.Fprintf(cs, "%s rg %.3f %.3f %.3f %.3f re f\n", pdfColor(l.bg), x, y, size, size)
+	fmt.Fprintf(cs, "%s rg\n", pdfColor(l.fg))
+	for _, rn := range l.runs() {
+		fmt.Fprintf(cs, "%.3f %.3f %.3f %.3f re\n",
+			x+float64(rn.x)*unit, y+size-float64(rn.y+1)*unit, float64(rn.w)*unit, unit)
+	}
+	cs.WriteString("f\n")
+
+	if l.logo != nil {
+		p := l.padBox
+		fmt.Fprintf(cs, "%s rg %.3f %.3f %.3f %.3f re f\n",
+			pdfColor(l.bg), x+p[0]*unit, y+size-(p[1]+p[3])*unit, p[2]*unit, p[3]*unit)
+		b := l.logoBox
+		fmt.Fprintf(cs, "q %.3f 0 0 %.3f %.3f %.3f cm /%s Do Q\n",
+			b[2]*unit, b[3]*unit, x+b[0]*unit, y+size-(b[1]+b[3])*unit, logo)
+	}
+}
+
+// pdfLogo returns the logo, flattened onto the background, as an image
+// XObject.
+func (l *layout) pdfLogo() (string, error) {
+	flat := l.flatLogo()
+	data, err := deflateRGB(flat)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(
+		"<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+		flat.Bounds().Dx(), flat.Bounds().Dy(), len(data), data), nil
+}
+
+func pdfStream(data []byte) string {
+	return fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(data), data)
+}
+
+// writePDF numbers objects from 1 in order and adds the cross-reference
+// table. The first object must be the catalog.
+func writePDF(objects []string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+func deflateRGB(img *image.RGBA) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	b := img.Bounds()
+	row := make([]byte, 0, b.Dx()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row = row[:0]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			row = append(row, c.R, c.G, c.B)
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EPS renders the code as Encapsulated PostScript for print workflows.
+func (r *Renderer) EPS(ctx context.Context, content string, style Style, widthMM float64) ([]byte, error) {
+	l, err := r.layout(ctx, content, style)
+	if err != nil {
+		return nil, err
+	}
+	page := points(style, widthMM)
+	unit := page / float64(l.size)
+
+	var b bytes.Buffer
+	b.WriteString("%!PS-Adobe-3.0 EPSF-3.0\n")
+	fmt.Fprintf(&b, "%%%%BoundingBox: 0 0 %d %d\n", int(page+0.999), int(page+0.999))
+	fmt.Fprintf(&b, "%%%%HiResBoundingBox: 0 0 %.3f %.3f\n", page, page)
+	b.WriteString("%%Creator: shorty\n%%EndComments\n")
+	b.WriteString("gsave\n")
+	fmt.Fprintf(&b, "%s setrgbcolor 0 0 %.3f %.3f rectfill\n", pdfColor(l.bg), page, page)
+	fmt.Fprintf(&b, "%s setrgbcolor\n", pdfColor(l.fg))
+	for _, rn := range l.runs() {
+		fmt.Fprintf(&b, "%.3f %.3f %.3f %.3f rectfill\n",
+			float64(rn.x)*unit, page-float64(rn.y+1)*unit, float64(rn.w)*unit, unit)
+	}
+
+	if l.logo != nil {
+		p := l.padBox
+		fmt.Fprintf(&b, "%s setrgbcolor %.3f %.3f %.3f %.3f rectfill\n",
+			pdfColor(l.bg), p[0]*unit, page-(p[1]+p[3])*unit, p[2]*unit, p[3]*unit)
+
+		flat := l.flatLogo()
+		w, h := flat.Bounds().Dx(), flat.Bounds().Dy()
+		box := l.logoBox
+		fmt.Fprintf(&b, "gsave %.3f %.3f translate %.3f %.3f scale\n",
+			box[0]*unit, page-(box[1]+box[3])*unit, box[2]*unit, box[3]*unit)
+		fmt.Fprintf(&b, "/picstr %d string def\n", w*3)
+		fmt.Fprintf(&b, "%d %d 8 [%d 0 0 -%d 0 %d] {currentfile picstr readhexstring pop} false 3 colorimage\n",
+			w, h, w, h, h)
+		row := make([]byte, 0, w*3)
+		for y := 0; y < h; y++ {
+			row = row[:0]
+			for x := 0; x < w; x++ {
+				c := flat.RGBAAt(x, y)
+				row = append(row, c.R, c.G, c.B)
+			}
+			b.WriteString(hex.EncodeToString(row))
+			b.WriteByte('\n')
+		}
+		b.WriteString("grestore\n")
+	}
+
+	b.WriteString("grestore\nshowpage\n%%EOF\n")
+	return b.Bytes(), nil
+}
@@ -0,0 +1,323 @@
+package qr
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeLogos returns a 4×2 red logo for any URL.
+type fakeLogos struct{}
+
+func (fakeLogos) Fetch(ctx context.Context, url string) (image.Image, error) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+		if i%4 == 1 || i%4 == 2 {
+			img.Pix[i] = 0
+		}
+	}
+	return img, nil
+}
+
+func testStyle(t *testing.T, logo bool) Style {
+	t.Helper()
+	style := DefaultStyle()
+	style.Foreground = "#112233"
+	if logo {
+		style.LogoURL = "https://example.com/logo.png"
+	}
+	if err := style.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	return style
+}
+
+// grid is the symbol with its quiet zone, as the vector writers see it.
+func grid(t *testing.T, content string, style Style) [][]bool {
+	t.Helper()
+	modules, err := Modules(content, style)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := len(modules) + 2*style.QuietZone
+	g := make([][]bool, n)
+	for y := range g {
+		g[y] = make([]bool, n)
+		if my := y - style.QuietZone; my >= 0 && my < len(modules) {
+			for x := range modules[my] {
+				g[y][x+style.QuietZone] = modules[my][x]
+			}
+		}
+	}
+	return g
+}
+
+// paint marks the w×1 module runs described by coords on an n×n grid.
+func paint(n int, coords [][3]int) [][]bool {
+	g := make([][]bool, n)
+	for y := range g {
+		g[y] = make([]bool, n)
+	}
+	for _, c := range coords {
+		for x := c[0]; x < c[0]+c[2]; x++ {
+			g[c[1]][x] = true
+		}
+	}
+	return g
+}
+
+func equalGrids(a, b [][]bool) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func TestRuns(t *testing.T) {
+	tests := []struct {
+		modules [][]bool
+		quiet   int
+		want    []run
+	}{
+		{[][]bool{{false, false}, {false, false}}, 0, nil},
+		{[][]bool{{true, true, true}}, 0, []run{{0, 0, 3}}},
+		{[][]bool{{true, false, true}}, 0, []run{{0, 0, 1}, {2, 0, 1}}},
+		{[][]bool{{false, true, true}, {true, true, false}}, 0, []run{{1, 0, 2}, {0, 1, 2}}},
+		{[][]bool{{true, false}, {false, true}}, 4, []run{{4, 4, 1}, {5, 5, 1}}},
+	}
+	for _, tt := range tests {
+		l := &layout{modules: tt.modules, quiet: tt.quiet}
+		if got := l.runs(); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("runs(%v, quiet %d) = %v, want %v", tt.modules, tt.quiet, got, tt.want)
+		}
+	}
+}
+
+func TestPoints(t *testing.T) {
+	tests := []struct {
+		size    int
+		widthMM float64
+		want    float64
+	}{
+		{256, 0, 192},
+		{96, 0, 72},
+		{256, 25.4, 72},
+		{256, 50.8, 144},
+	}
+	for _, tt := range tests {
+		if got := points(Style{Size: tt.size}, tt.widthMM); got != tt.want {
+			t.Errorf("points(%d px, %g mm) = %g, want %g", tt.size, tt.widthMM, got, tt.want)
+		}
+	}
+}
+
+var svgRun = regexp.MustCompile(`M(\d+) (\d+)h(\d+)v1h-(\d+)z`)
+
+func TestSVG(t *testing.T) {
+	tests := []struct {
+		name    string
+		widthMM float64
+		logo    bool
+		dim     string
+	}{
+		{"pixels", 0, false, "256"},
+		{"millimetres", 30, false, "30mm"},
+		{"logo", 25.5, true, "25.5mm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style := testStyle(t, tt.logo)
+			out, err := NewRenderer(fakeLogos{}, nil).SVG(context.Background(), "https://sho.rt/abc", style, tt.widthMM)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var doc struct {
+				Width   string `xml:"width,attr"`
+				Height  string `xml:"height,attr"`
+				ViewBox string `xml:"viewBox,attr"`
+				Rects   []struct {
+					Fill string `xml:"fill,attr"`
+				} `xml:"rect"`
+				Path struct {
+					Fill string `xml:"fill,attr"`
+					D    string `xml:"d,attr"`
+				} `xml:"path"`
+				Images []struct {
+					Href string `xml:"href,attr"`
+				} `xml:"image"`
+			}
+			if err := xml.Unmarshal(out, &doc); err != nil {
+				t.Fatalf("invalid SVG: %v", err)
+			}
+
+			want := grid(t, "https://sho.rt/abc", style)
+			if doc.Width != tt.dim || doc.Height != tt.dim {
+				t.Errorf("size = %s×%s, want %s", doc.Width, doc.Height, tt.dim)
+			}
+			if vb := fmt.Sprintf("0 0 %d %d", len(want), len(want)); doc.ViewBox != vb {
+				t.Errorf("viewBox = %q, want %q", doc.ViewBox, vb)
+			}
+			if doc.Path.Fill != "#112233" || doc.Rects[0].Fill != "#ffffff" {
+				t.Errorf("colors = %s on %s", doc.Path.Fill, doc.Rects[0].Fill)
+			}
+
+			var coords [][3]int
+			for _, m := range svgRun.FindAllStringSubmatch(doc.Path.D, -1) {
+				x, _ := strconv.Atoi(m[1])
+				y, _ := strconv.Atoi(m[2])
+				w, _ := strconv.Atoi(m[3])
+				coords = append(coords, [3]int{x, y, w})
+			}
+			if strings.Count(doc.Path.D, "z") != len(coords) {
+				t.Errorf("path has unparsed segments: %q", doc.Path.D)
+			}
+			if !equalGrids(paint(len(want), coords), want) {
+				t.Error("path does not reproduce the modules")
+			}
+
+			if got := len(doc.Images); got != map[bool]int{false: 0, true: 1}[tt.logo] {
+				t.Errorf("%d logo images", got)
+			}
+			if tt.logo && !strings.HasPrefix(doc.Images[0].Href, "data:image/png;base64,") {
+				t.Errorf("logo href = %.40q", doc.Images[0].Href)
+			}
+		})
+	}
+}
+
+var pdfRect = regexp.MustCompile(`(?m)^([\d.]+) ([\d.]+) ([\d.]+) ([\d.]+) re$`)
+
+func TestPDF(t *testing.T) {
+	for _, logo := range []bool{false, true} {
+		t.Run(fmt.Sprintf("logo=%v", logo), func(t *testing.T) {
+			style := testStyle(t, logo)
+			out, err := NewRenderer(fakeLogos{}, nil).PDF(context.Background(), "https://sho.rt/abc", style, 25.4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+				t.Fatal("missing PDF header or trailer")
+			}
+
+			objects := 4
+			if logo {
+				objects = 5
+			}
+			checkXref(t, out, objects)
+			if !bytes.Contains(out, []byte("/MediaBox [0 0 72.000 72.000]")) {
+				t.Error("page is not 1 inch square")
+			}
+			if got := bytes.Contains(out, []byte("/Logo Do")); got != logo {
+				t.Errorf("logo drawn = %v", got)
+			}
+
+			// Module rects, flipped back to top-down rows, reproduce the
+			// code.
+			want := grid(t, "https://sho.rt/abc", style)
+			unit := 72.0 / float64(len(want))
+			var coords [][3]int
+			for _, m := range pdfRect.FindAllStringSubmatch(string(out), -1) {
+				x, _ := strconv.ParseFloat(m[1], 64)
+				y, _ := strconv.ParseFloat(m[2], 64)
+				w, _ := strconv.ParseFloat(m[3], 64)
+				coords = append(coords, [3]int{
+					int(x/unit + 0.5), len(want) - 1 - int(y/unit+0.5), int(w/unit + 0.5),
+				})
+			}
+			if !equalGrids(paint(len(want), coords), want) {
+				t.Error("rects do not reproduce the modules")
+			}
+		})
+	}
+}
+
+// checkXref verifies that the cross-reference table points at each object.
+func checkXref(t *testing.T, pdf []byte, objects int) {
+	t.Helper()
+	i := bytes.LastIndex(pdf, []byte("startxref\n"))
+	xref, err := strconv.Atoi(strings.Fields(string(pdf[i+len("startxref\n"):]))[0])
+	if err != nil || !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref does not point at the xref table")
+	}
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	if lines[1] != fmt.Sprintf("0 %d", objects+1) {
+		t.Fatalf("xref covers %q, want %d objects", lines[1], objects)
+	}
+	for n := 1; n <= objects; n++ {
+		off, _ := strconv.Atoi(lines[2+n][:10])
+		if !bytes.HasPrefix(pdf[off:], []byte(fmt.Sprintf("%d 0 obj\n", n))) {
+			t.Errorf("xref entry %d points at %.20q", n, pdf[off:])
+		}
+	}
+}
+
+func TestEPS(t *testing.T) {
+	for _, logo := range []bool{false, true} {
+		t.Run(fmt.Sprintf("logo=%v", logo), func(t *testing.T) {
+			style := testStyle(t, logo)
+			out, err := NewRenderer(fakeLogos{}, nil).EPS(context.Background(), "https://sho.rt/abc", style, 50)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := string(out)
+			if !strings.HasPrefix(s, "%!PS-Adobe-3.0 EPSF-3.0\n") || !strings.HasSuffix(s, "%%EOF\n") {
+				t.Fatal("missing EPS header or trailer")
+			}
+			if !strings.Contains(s, "%%BoundingBox: 0 0 142 142\n") || !strings.Contains(s, "%%HiResBoundingBox: 0 0 141.732 141.732\n") {
+				t.Error("wrong bounding box for 50 mm")
+			}
+
+			l, err := NewRenderer(fakeLogos{}, nil).layout(context.Background(), "https://sho.rt/abc", style)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The background, one rect per run, and the logo pad.
+			want := 1 + len(l.runs())
+			if logo {
+				want++
+			}
+			if got := strings.Count(s, "rectfill\n"); got != want {
+				t.Errorf("%d rectfills, want %d", got, want)
+			}
+			if got := strings.Contains(s, "colorimage"); got != logo {
+				t.Errorf("logo image = %v", got)
+			}
+		})
+	}
+}
+
+func TestLayoutLogoBox(t *testing.T) {
+	style := testStyle(t, true)
+	l, err := NewRenderer(fakeLogos{}, nil).layout(context.Background(), "https://sho.rt/abc", style)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The 2:1 logo is scaled up to the raster size and centred, keeping
+	// its aspect ratio inside the padded box.
+	if b := l.logo.Bounds(); b.Dx() != logoRasterSize || b.Dy() != logoRasterSize/2 {
+		t.Errorf("logo raster = %v", b)
+	}
+	center := float64(l.size) / 2
+	pad, box := l.padBox, l.logoBox
+	if pad[0]+pad[2]/2 != center || box[0]+box[2]/2 != center || box[1]+box[3]/2 != center {
+		t.Errorf("boxes not centred: pad %v, logo %v, centre %g", pad, box, center)
+	}
+	if box[2] != 2*box[3] {
+		t.Errorf("logo box %v does not keep the 2:1 aspect", box)
+	}
+	if box[0] < pad[0] || box[0]+box[2] > pad[0]+pad[2] {
+		t.Errorf("logo box %v outside pad %v", box, pad)
+	}
+
+	flat := l.flatLogo()
+	if c := flat.RGBAAt(0, 0); c != (color.RGBA{0xff, 0, 0, 0xff}) {
+		t.Errorf("flattened logo pixel = %v", c)
+	}
+}
@@ -0,0 +1,152 @@
+package reaper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/url"
+)
+
+const (
+	ModeArchive = "archive"
+	ModePurge   = "purge"
+)
+
+const (
+	batchSize = 500
+	// orphanMinAge protects images whose link row is still being written.
+	orphanMinAge = time.Hour
+)
+
+type Config struct {
+	GracePeriod time.Duration
+	Mode        string
+	Interval    time.Duration
+}
+
+// Reaper periodically removes links that expired more than GracePeriod ago,
+// either archiving or purging them, deletes their QR images, and removes
+// stored QR images that no longer belong to any link.
+type Reaper struct {
+	urlRepo url.Repository
+	store   storage.Storage
+	cfg     Config
+}
+
+func New(urlRepo url.Repository, store storage.Storage, cfg Config) (*Reaper, error) {
+	if cfg.Mode != ModeArchive && cfg.Mode != ModePurge {
+		return nil, errors.New("reaper mode must be archive or purge")
+	}
+	return &Reaper{urlRepo: urlRepo, store: store, cfg: cfg}, nil
+}
+
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.RunOnce(ctx); err != nil {
+			log.Printf("❌ Reaper run failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Reaper) RunOnce(ctx context.Context) error {
+	reaped, err := r.reapExpired(ctx)
+	if err != nil {
+		return err
+	}
+	orphans, err := r.reconcileAssets(ctx)
+	if err != nil {
+		return err
+	}
+	if reaped > 0 || orphans > 0 {
+		log.Printf("🧹 Reaper removed %d expired link(s) and %d orphaned QR image(s)", reaped, orphans)
+	}
+	return nil
+}
+
+func (r *Reaper) reapExpired(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-r.cfg.GracePeriod)
+	reaped := 0
+	var lastID int64
+	for {
+		links, err := r.urlRepo.ListExpiredBefore(cutoff, lastID, batchSize)
+		if err != nil {
+			return reaped, fmt.Errorf("failed to list expired links: %w", err)
+		}
+
+		for _, u := range links {
+			// Paging by id moves past links that failed; the next run
+			// retries them.
+			lastID = u.ID
+			if r.cfg.Mode == ModeArchive {
+				err = r.urlRepo.Archive(u.ID)
+			} else {
+				err = r.urlRepo.DeleteByID(u.ID)
+			}
+			if err != nil {
+				log.Printf("❌ Failed to %s expired link %d: %v", r.cfg.Mode, u.ID, err)
+				continue
+			}
+			reaped++
+
+			// A failed delete leaves an orphan that reconcileAssets retries.
+			if u.QRURL != "" {
+				if err := r.store.Delete(ctx, url.QRObjectKey(u)); err != nil {
+					log.Printf("❌ Failed to delete QR image for link %d: %v", u.ID, err)
+				}
+			}
+		}
+
+		if len(links) < batchSize || ctx.Err() != nil {
+			return reaped, ctx.Err()
+		}
+	}
+}
+
+func (r *Reaper) reconcileAssets(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-orphanMinAge)
+	removed := 0
+	err := r.store.List(ctx, url.QRPrefix, func(objects []storage.Object) error {
+		// Objects are matched by key: their URLs depend on the public URL
+		// settings, which can change.
+		var codes []string
+		for _, o := range objects {
+			codes = append(codes, url.QRObjectCodes(o.Key)...)
+		}
+		links, err := r.urlRepo.ListByShortCodes(codes)
+		if err != nil {
+			return fmt.Errorf("failed to match QR images: %w", err)
+		}
+		existing := make(map[string]bool, len(links))
+		for _, u := range links {
+			existing[url.QRObjectKey(u)] = true
+		}
+
+		for _, o := range objects {
+			if existing[o.Key] || o.CreatedAt.After(cutoff) {
+				continue
+			}
+			if err := r.store.Delete(ctx, o.Key); err != nil {
+				log.Printf("❌ Failed to delete orphaned QR image %s: %v", o.Key, err)
+				continue
+			}
+			removed++
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to reconcile QR images: %w", err)
+	}
+	return removed, nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path"
+	"strings"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/admin"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+type cloudinaryStorage struct {
+	cld *cloudinary.Cloudinary
+}
+
+func NewCloudinary(cloudName, apiKey, apiSecret string) (Storage, error) {
+	cld, err := cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudinaryStorage{cld: cld}, nil
+}
+
+// publicID drops the extension: Cloudinary tracks the format separately.
+func publicID(key string) string {
+	return strings.TrimSuffix(key, path.Ext(key))
+}
+
+func (s *cloudinaryStorage) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	res, err := s.cld.Upload.Upload(ctx, bytes.NewReader(data), uploader.UploadParams{
+		PublicID:   publicID(key),
+		Overwrite:  api.Bool(true),
+		Invalidate: api.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	if res.Error.Message != "" {
+		return "", errors.New("cloudinary: " + res.Error.Message)
+	}
+	return res.SecureURL, nil
+}
+
+func (s *cloudinaryStorage) Delete(ctx context.Context, key string) error {
+	res, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: publicID(key)})
+	if err != nil {
+		return err
+	}
+	if res.Error.Message != "" {
+		return errors.New("cloudinary: " + res.Error.Message)
+	}
+	return nil
+}
+
+func (s *cloudinaryStorage) List(ctx context.Context, prefix string, fn func([]Object) error) error {
+	params := admin.AssetsParams{DeliveryType: "upload", Prefix: prefix, MaxResults: 500}
+	for {
+		res, err := s.cld.Admin.Assets(ctx, params)
+		if err != nil {
+			return err
+		}
+		if res.Error.Message != "" {
+			return errors.New("cloudinary: " + res.Error.Message)
+		}
+
+		page := make([]Object, 0, len(res.Assets))
+		for _, a := range res.Assets {
+			page = append(page, Object{Key: a.PublicID + "." + a.Format, URL: a.SecureURL, CreatedAt: a.CreatedAt})
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+
+		if res.NextCursor == "" {
+			return nil
+		}
+		params.NextCursor = res.NextCursor
+	}
+}
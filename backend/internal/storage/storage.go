@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Object is one stored file as returned by List.
+type Object struct {
+	Key       string
+	URL       string
+	CreatedAt time.Time
+}
+
+// Storage keeps public files such as QR images. Keys are slash-separated
+// paths including the extension, e.g. "qr_codes/qr_abc.png".
+type Storage interface {
+	// Put stores data under key, replacing any existing object, and returns
+	// its public URL.
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	// Delete removes key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// List hands every object whose key starts with prefix to fn, a page at
+	// a time.
+	List(ctx context.Context, prefix string, fn func([]Object) error) error
+}
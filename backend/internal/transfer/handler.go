@@ -0,0 +1,161 @@
+package transfer
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"url-shortener/internal/workspace"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+type filterRequest struct {
+	Search        string     `json:"search"`
+	CreatedAfter  *time.Time `json:"created_after"`
+	CreatedBefore *time.Time `json:"created_before"`
+}
+
+type createRequest struct {
+	URLIDs          []int64        `json:"url_ids"`
+	All             bool           `json:"all"`
+	Filter          *filterRequest `json:"filter"`
+	FromWorkspaceID *int64         `json:"from_workspace_id"`
+	FromUsername    string         `json:"from_username"`
+	ToUsername      string         `json:"to_username"`
+	ToWorkspaceID   *int64         `json:"to_workspace_id"`
+}
+
+func writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrTransferNotFound), errors.Is(err, workspace.ErrWorkspaceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrAdminOnly), errors.Is(err, workspace.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// POST /api/transfers
+func (h *Handler) Create(c *gin.Context) {
+	var req createRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	in := CreateInput{
+		URLIDs:          req.URLIDs,
+		All:             req.All,
+		FromWorkspaceID: req.FromWorkspaceID,
+		FromUsername:    req.FromUsername,
+		ToUsername:      req.ToUsername,
+		ToWorkspaceID:   req.ToWorkspaceID,
+	}
+	if req.Filter != nil {
+		in.Filter = &Filter{
+			Search:        req.Filter.Search,
+			CreatedAfter:  req.Filter.CreatedAfter,
+			CreatedBefore: req.Filter.CreatedBefore,
+		}
+	}
+
+	t, err := h.service.Create(userID.(int64), in)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, t)
+}
+
+// GET /api/transfers
+func (h *Handler) List(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	list, err := h.service.List(userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if list == nil {
+		list = []*Transfer{}
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// POST /api/transfers/:id/accept
+func (h *Handler) Accept(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	t, err := h.service.Accept(userID.(int64), id)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// POST /api/transfers/:id/decline
+func (h *Handler) Decline(c *gin.Context) {
+	h.respond(c, h.service.Decline, "Transfer declined")
+}
+
+// POST /api/transfers/:id/cancel
+func (h *Handler) Cancel(c *gin.Context) {
+	h.respond(c, h.service.Cancel, "Transfer cancelled")
+}
+
+func (h *Handler) respond(c *gin.Context, action func(userID, id int64) error, message string) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := action(userID.(int64), id); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
@@ -0,0 +1,41 @@
+package transfer
+
+import "time"
+
+const (
+	StatusPending   = "pending"
+	StatusAccepted  = "accepted"
+	StatusDeclined  = "declined"
+	StatusCancelled = "cancelled"
+)
+
+// Transfer moves a set of links to another user or workspace once the
+// recipient accepts. Click history and QR images follow automatically since
+// they hang off the link row. RequestedBy is who created the transfer: the
+// owner, or a site admin handing over the links of a user who has left.
+type Transfer struct {
+	ID            int64      `json:"id"`
+	FromUserID    int64      `json:"from_user_id"`
+	RequestedBy   int64      `json:"requested_by"`
+	ToUserID      *int64     `json:"to_user_id,omitempty"`
+	ToWorkspaceID *int64     `json:"to_workspace_id,omitempty"`
+	Status        string     `json:"status"`
+	Items         []Item     `json:"items"`
+	CreatedAt     time.Time  `json:"created_at"`
+	RespondedAt   *time.Time `json:"responded_at"`
+}
+
+// Item is one link in a transfer, with the workspace it was in when the
+// transfer was requested (nil for personal links, or once that workspace
+// has been deleted).
+type Item struct {
+	URLID           int64  `json:"url_id"`
+	FromWorkspaceID *int64 `json:"from_workspace_id,omitempty"`
+}
+
+// Filter selects links from a personal or workspace scope.
+type Filter struct {
+	Search        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
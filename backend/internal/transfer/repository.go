@@ -0,0 +1,297 @@
+package transfer
+
+import (
+	"database/sql"
+	"errors"
+	"url-shortener/internal/audit"
+)
+
+var errNotPending = errors.New("transfer is no longer pending")
+
+type Repository interface {
+	MatchLinks(userID int64, workspaceID *int64, f Filter) ([]int64, error)
+	// Create stores a pending transfer and its audit entry.
+	Create(t *Transfer) (int64, error)
+	GetByID(id int64) (*Transfer, error)
+	ListForUser(userID int64) ([]*Transfer, error)
+	// Accept moves every item still in its original scope, recording actorID
+	// in the audit log, and returns the IDs of the links that moved.
+	Accept(id, actorID int64) ([]int64, error)
+	SetStatus(id int64, status string) error
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) MatchLinks(userID int64, workspaceID *int64, f Filter) ([]int64, error) {
+	rows, err := r.db.Query(`
+		SELECT u.id
+		FROM urls u
+		WHERE (($2::int IS NULL AND u.user_id = $1 AND u.workspace_id IS NULL) OR u.workspace_id = $2)
+		  AND ($3 = '' OR u.original_url ILIKE '%' || $3 || '%' OR u.short_code ILIKE '%' || $3 || '%')
+		  AND ($4::timestamp IS NULL OR u.created_at >= $4)
+		  AND ($5::timestamp IS NULL OR u.created_at < $5)
+		ORDER BY u.id`,
+		userID, workspaceID, f.Search, f.CreatedAfter, f.CreatedBefore,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *repository) Create(t *Transfer) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(
+		"INSERT INTO url_transfers (from_user_id, requested_by, to_user_id, to_workspace_id, status) VALUES ($1,$2,$3,$4,$5) RETURNING id",
+		t.FromUserID, t.RequestedBy, t.ToUserID, t.ToWorkspaceID, StatusPending,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO url_transfer_items (transfer_id, url_id, from_workspace_id) VALUES ($1,$2,$3)")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, item := range t.Items {
+		if _, err := stmt.Exec(id, item.URLID, item.FromWorkspaceID); err != nil {
+			return 0, err
+		}
+	}
+
+	err = audit.Record(tx, t.RequestedBy, audit.ActionTransferRequested, "transfer", id, map[string]any{
+		"from_user_id":    t.FromUserID,
+		"to_user_id":      t.ToUserID,
+		"to_workspace_id": t.ToWorkspaceID,
+		"links":           len(t.Items),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *repository) GetByID(id int64) (*Transfer, error) {
+	t := &Transfer{}
+	err := r.db.QueryRow(
+		`SELECT id, from_user_id, COALESCE(requested_by, from_user_id), to_user_id, to_workspace_id, status, created_at, responded_at
+		FROM url_transfers WHERE id=$1`,
+		id,
+	).Scan(&t.ID, &t.FromUserID, &t.RequestedBy, &t.ToUserID, &t.ToWorkspaceID, &t.Status, &t.CreatedAt, &t.RespondedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	items, err := r.listItems(id)
+	if err != nil {
+		return nil, err
+	}
+	t.Items = items
+	return t, nil
+}
+
+func (r *repository) listItems(transferID int64) ([]Item, error) {
+	rows, err := r.db.Query(
+		"SELECT url_id, from_workspace_id FROM url_transfer_items WHERE transfer_id=$1 ORDER BY url_id",
+		transferID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []Item
+	for rows.Next() {
+		var item Item
+		if err := rows.Scan(&item.URLID, &item.FromWorkspaceID); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ListForUser returns transfers the user started or whose links they own,
+// was offered, or can accept as an owner or admin of the receiving
+// workspace.
+func (r *repository) ListForUser(userID int64) ([]*Transfer, error) {
+	rows, err := r.db.Query(`
+		SELECT t.id, t.from_user_id, COALESCE(t.requested_by, t.from_user_id), t.to_user_id, t.to_workspace_id, t.status, t.created_at, t.responded_at
+		FROM url_transfers t
+		WHERE t.from_user_id = $1
+		   OR t.requested_by = $1
+		   OR t.to_user_id = $1
+		   OR t.to_workspace_id IN (
+			SELECT workspace_id FROM workspace_members
+			WHERE user_id = $1 AND role IN ('owner', 'admin')
+		   )
+		ORDER BY t.created_at DESC
+		LIMIT 100`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Transfer
+	for rows.Next() {
+		t := &Transfer{}
+		if err := rows.Scan(&t.ID, &t.FromUserID, &t.RequestedBy, &t.ToUserID, &t.ToWorkspaceID, &t.Status, &t.CreatedAt, &t.RespondedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, t := range list {
+		if t.Items, err = r.listItems(t.ID); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func (r *repository) Accept(id, actorID int64) ([]int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var fromUserID int64
+	var toUserID, toWorkspaceID *int64
+	var status string
+	err = tx.QueryRow(
+		"SELECT from_user_id, to_user_id, to_workspace_id, status FROM url_transfers WHERE id=$1 FOR UPDATE",
+		id,
+	).Scan(&fromUserID, &toUserID, &toWorkspaceID, &status)
+	if err != nil {
+		return nil, err
+	}
+	if status != StatusPending {
+		return nil, errNotPending
+	}
+
+	// Links that changed hands since the request was made are skipped.
+	// Custom domains stay with their owner, who controls their DNS, so links
+	// on one move to the default host with their QR image queued for a
+	// redraw; a link whose code is taken there is skipped too.
+	rows, err := tx.Query(`
+		UPDATE urls u
+		SET user_id = COALESCE($2, u.user_id), workspace_id = $3, domain_id = NULL,
+			qr_status = CASE WHEN u.domain_id IS NULL THEN u.qr_status ELSE 'pending' END,
+			qr_attempts = CASE WHEN u.domain_id IS NULL THEN u.qr_attempts ELSE 0 END,
+			qr_next_attempt_at = CASE WHEN u.domain_id IS NULL THEN u.qr_next_attempt_at ELSE NOW() END,
+			qr_error = CASE WHEN u.domain_id IS NULL THEN u.qr_error ELSE '' END,
+			qr_version = CASE WHEN u.domain_id IS NULL THEN u.qr_version ELSE u.qr_version + 1 END
+		FROM url_transfer_items i
+		WHERE i.transfer_id = $1
+		  AND u.id = i.url_id
+		  AND u.workspace_id IS NOT DISTINCT FROM i.from_workspace_id
+		  AND (i.from_workspace_id IS NOT NULL OR u.user_id = $4)
+		  AND (u.domain_id IS NULL OR NOT EXISTS (
+			SELECT 1 FROM urls o WHERE o.domain_id IS NULL AND o.short_code = u.short_code
+		  ))
+		RETURNING u.id`,
+		id, toUserID, toWorkspaceID, fromUserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var moved []int64
+	for rows.Next() {
+		var urlID int64
+		if err := rows.Scan(&urlID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		moved = append(moved, urlID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE url_transfers SET status=$1, responded_at=NOW() WHERE id=$2",
+		StatusAccepted, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var items int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM url_transfer_items WHERE transfer_id=$1", id).Scan(&items); err != nil {
+		return nil, err
+	}
+	err = audit.Record(tx, actorID, audit.ActionTransferAccepted, "transfer", id, map[string]any{
+		"moved":   len(moved),
+		"skipped": items - len(moved),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, urlID := range moved {
+		err := audit.Record(tx, actorID, audit.ActionLinkTransferred, "url", urlID, map[string]any{
+			"transfer_id":     id,
+			"from_user_id":    fromUserID,
+			"to_user_id":      toUserID,
+			"to_workspace_id": toWorkspaceID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return moved, tx.Commit()
+}
+
+func (r *repository) SetStatus(id int64, status string) error {
+	res, err := r.db.Exec(
+		"UPDATE url_transfers SET status=$1, responded_at=NOW() WHERE id=$2 AND status=$3",
+		status, id, StatusPending,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errNotPending
+	}
+	return nil
+}
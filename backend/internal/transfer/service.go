@@ -0,0 +1,307 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"url-shortener/internal/notification"
+	"url-shortener/internal/url"
+	"url-shortener/internal/user"
+	"url-shortener/internal/workspace"
+)
+
+const maxLinksPerTransfer = 10000
+
+var (
+	ErrTransferNotFound = errors.New("transfer not found")
+	ErrNotPending       = errNotPending
+	ErrForbidden        = errors.New("you cannot act on this transfer")
+	ErrAdminOnly        = errors.New("only site admins can transfer another user's links")
+)
+
+// CreateInput selects links either by ID or, when All or Filter is set, from
+// the caller's personal links or FromWorkspaceID. Site admins may instead
+// set FromUsername to select from another user's personal links, to hand
+// over the links of someone who has left. The recipient is a user
+// (ToUsername) or a workspace (ToWorkspaceID).
+type CreateInput struct {
+	URLIDs          []int64
+	All             bool
+	Filter          *Filter
+	FromWorkspaceID *int64
+	FromUsername    string
+	ToUsername      string
+	ToWorkspaceID   *int64
+}
+
+type Service interface {
+	Create(userID int64, in CreateInput) (*Transfer, error)
+	List(userID int64) ([]*Transfer, error)
+	Accept(userID, id int64) (*Transfer, error)
+	Decline(userID, id int64) error
+	Cancel(userID, id int64) error
+}
+
+type service struct {
+	repo                Repository
+	urlRepo             url.Repository
+	userRepo            user.Repository
+	workspaceService    workspace.Service
+	notificationService notification.Service
+}
+
+func NewService(
+	repo Repository,
+	urlRepo url.Repository,
+	userRepo user.Repository,
+	workspaceService workspace.Service,
+	notificationService notification.Service,
+) Service {
+	return &service{
+		repo:                repo,
+		urlRepo:             urlRepo,
+		userRepo:            userRepo,
+		workspaceService:    workspaceService,
+		notificationService: notificationService,
+	}
+}
+
+func (s *service) Create(userID int64, in CreateInput) (*Transfer, error) {
+	ownerID, err := s.owner(userID, in)
+	if err != nil {
+		return nil, err
+	}
+	t := &Transfer{FromUserID: ownerID, RequestedBy: userID, Status: StatusPending}
+
+	switch {
+	case in.ToUsername != "" && in.ToWorkspaceID != nil:
+		return nil, errors.New("choose either to_username or to_workspace_id")
+	case in.ToUsername != "":
+		recipient, err := s.userRepo.GetByUsername(in.ToUsername)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load recipient: %w", err)
+		}
+		if recipient == nil {
+			return nil, errors.New("recipient not found")
+		}
+		if int64(recipient.ID) == ownerID {
+			return nil, errors.New("cannot transfer links to their current owner")
+		}
+		id := int64(recipient.ID)
+		t.ToUserID = &id
+	case in.ToWorkspaceID != nil:
+		if _, err := s.workspaceService.Get(*in.ToWorkspaceID); err != nil {
+			return nil, err
+		}
+		t.ToWorkspaceID = in.ToWorkspaceID
+	default:
+		return nil, errors.New("a recipient is required")
+	}
+
+	items, err := s.selectItems(ownerID, in)
+	if err != nil {
+		return nil, err
+	}
+	// Links already in the target workspace have nowhere to move.
+	for _, item := range items {
+		if t.ToWorkspaceID == nil || item.FromWorkspaceID == nil || *item.FromWorkspaceID != *t.ToWorkspaceID {
+			t.Items = append(t.Items, item)
+		}
+	}
+	if len(t.Items) == 0 {
+		return nil, errors.New("no links matched")
+	}
+	if len(t.Items) > maxLinksPerTransfer {
+		return nil, fmt.Errorf("too many links (max %d per transfer)", maxLinksPerTransfer)
+	}
+
+	id, err := s.repo.Create(t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transfer: %w", err)
+	}
+	t.ID = id
+
+	s.notifyRecipient(t)
+
+	return s.repo.GetByID(id)
+}
+
+// owner returns whose links the caller is giving away: their own, or for a
+// site admin, the user named by FromUsername.
+func (s *service) owner(userID int64, in CreateInput) (int64, error) {
+	if in.FromUsername == "" {
+		return userID, nil
+	}
+
+	caller, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load user: %w", err)
+	}
+	if caller == nil || !caller.IsAdmin {
+		return 0, ErrAdminOnly
+	}
+	if len(in.URLIDs) > 0 || in.FromWorkspaceID != nil {
+		return 0, errors.New("from_username selects personal links with all or filter")
+	}
+
+	owner, err := s.userRepo.GetByUsername(in.FromUsername)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load user: %w", err)
+	}
+	if owner == nil {
+		return 0, errors.New("user not found")
+	}
+	return int64(owner.ID), nil
+}
+
+// selectItems resolves the links the caller asked for and checks they may
+// give each one away: personal links must be theirs, workspace links need
+// an owner or admin role.
+func (s *service) selectItems(userID int64, in CreateInput) ([]Item, error) {
+	byID := len(in.URLIDs) > 0
+	byScope := in.All || in.Filter != nil
+	if byID == byScope {
+		return nil, errors.New("choose either url_ids or all/filter")
+	}
+
+	if byScope {
+		if in.FromWorkspaceID != nil {
+			if _, err := s.workspaceService.Authorize(userID, *in.FromWorkspaceID, workspace.PermManage); err != nil {
+				return nil, err
+			}
+		}
+		filter := Filter{}
+		if in.Filter != nil {
+			filter = *in.Filter
+		}
+		ids, err := s.repo.MatchLinks(userID, in.FromWorkspaceID, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match links: %w", err)
+		}
+		items := make([]Item, 0, len(ids))
+		for _, id := range ids {
+			items = append(items, Item{URLID: id, FromWorkspaceID: in.FromWorkspaceID})
+		}
+		return items, nil
+	}
+
+	if len(in.URLIDs) > maxLinksPerTransfer {
+		return nil, fmt.Errorf("too many links (max %d per transfer)", maxLinksPerTransfer)
+	}
+
+	seen := make(map[int64]bool, len(in.URLIDs))
+	items := make([]Item, 0, len(in.URLIDs))
+	for _, id := range in.URLIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		u, err := s.urlRepo.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load URL %d: %w", id, err)
+		}
+		if u == nil {
+			return nil, fmt.Errorf("URL %d not found", id)
+		}
+		if u.WorkspaceID == nil {
+			if u.UserID != userID {
+				return nil, fmt.Errorf("URL %d not found", id)
+			}
+		} else if _, err := s.workspaceService.Authorize(userID, *u.WorkspaceID, workspace.PermManage); err != nil {
+			return nil, fmt.Errorf("URL %d: %w", id, err)
+		}
+		items = append(items, Item{URLID: id, FromWorkspaceID: u.WorkspaceID})
+	}
+	return items, nil
+}
+
+func (s *service) List(userID int64) ([]*Transfer, error) {
+	return s.repo.ListForUser(userID)
+}
+
+func (s *service) Accept(userID, id int64) (*Transfer, error) {
+	t, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRecipient(userID, t); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.Accept(id, userID); err != nil {
+		if errors.Is(err, errNotPending) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to accept transfer: %w", err)
+	}
+
+	return s.repo.GetByID(id)
+}
+
+func (s *service) Decline(userID, id int64) error {
+	t, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkRecipient(userID, t); err != nil {
+		return err
+	}
+	return s.repo.SetStatus(id, StatusDeclined)
+}
+
+func (s *service) Cancel(userID, id int64) error {
+	t, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	if t.FromUserID != userID && t.RequestedBy != userID {
+		return ErrForbidden
+	}
+	return s.repo.SetStatus(id, StatusCancelled)
+}
+
+func (s *service) get(id int64) (*Transfer, error) {
+	t, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load transfer: %w", err)
+	}
+	if t == nil {
+		return nil, ErrTransferNotFound
+	}
+	return t, nil
+}
+
+// checkRecipient lets the receiving user, or an owner or admin of the
+// receiving workspace, respond to a transfer.
+func (s *service) checkRecipient(userID int64, t *Transfer) error {
+	if t.ToUserID != nil {
+		if *t.ToUserID != userID {
+			return ErrForbidden
+		}
+		return nil
+	}
+	if _, err := s.workspaceService.Authorize(userID, *t.ToWorkspaceID, workspace.PermManage); err != nil {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (s *service) notifyRecipient(t *Transfer) {
+	recipientID := int64(0)
+	switch {
+	case t.ToUserID != nil:
+		recipientID = *t.ToUserID
+	case t.ToWorkspaceID != nil:
+		w, err := s.workspaceService.Get(*t.ToWorkspaceID)
+		if err != nil {
+			return
+		}
+		recipientID = w.OwnerID
+	}
+
+	message := fmt.Sprintf("You have been offered %d link(s). Review transfer #%d to accept or decline.", len(t.Items), t.ID)
+	if err := s.notificationService.Notify(recipientID, nil, notification.KindLinkTransfer, "Links transferred to you", message); err != nil {
+		log.Printf("❌ Failed to notify transfer recipient: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package url
+
+import (
+	"errors"
+	"fmt"
+	"url-shortener/internal/workspace"
+)
+
+var ErrForbidden = errors.New("insufficient permissions for this link")
+
+// authorize checks that the caller may perform perm on u. Personal links
+// belong to their creator alone; workspace links follow the caller's role.
+// Callers with no access at all get ErrURLNotFound.
+func (s *service) authorize(callerID int64, u *URL, perm workspace.Permission) error {
+	if u.WorkspaceID == nil {
+		if u.UserID != callerID {
+			return ErrURLNotFound
+		}
+		return nil
+	}
+
+	if err := s.authorizeScope(callerID, u.WorkspaceID, perm); err != nil {
+		if errors.Is(err, workspace.ErrWorkspaceNotFound) {
+			return ErrURLNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// authorizeScope checks perm on a workspace, or allows the caller's personal
+// scope when workspaceID is nil.
+func (s *service) authorizeScope(callerID int64, workspaceID *int64, perm workspace.Permission) error {
+	if workspaceID == nil {
+		return nil
+	}
+
+	_, err := s.workspaceService.Authorize(callerID, *workspaceID, perm)
+	if errors.Is(err, workspace.ErrForbidden) {
+		return ErrForbidden
+	}
+	return err
+}
+
+func (s *service) getAuthorized(callerID, id int64, perm workspace.Permission) (*URL, error) {
+	u, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load URL: %w", err)
+	}
+	if u == nil {
+		return nil, ErrURLNotFound
+	}
+	if err := s.authorize(callerID, u, perm); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
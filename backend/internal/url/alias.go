@@ -0,0 +1,60 @@
+package url
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrAliasTaken   = errors.New("alias is already in use")
+	ErrInvalidAlias = errors.New("invalid alias")
+	ErrDuplicateURL = errors.New("URL has already been shortened")
+)
+
+const (
+	minAliasLength = 3
+	maxAliasLength = 50
+)
+
+func validateAlias(alias string) error {
+	if len(alias) < minAliasLength || len(alias) > maxAliasLength {
+		return fmt.Errorf("%w: must be between %d and %d characters", ErrInvalidAlias, minAliasLength, maxAliasLength)
+	}
+	for _, char := range alias {
+		isValid := (char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') ||
+			char == '-' || char == '_'
+		if !isValid {
+			return fmt.Errorf("%w: contains invalid character '%c'", ErrInvalidAlias, char)
+		}
+	}
+	return nil
+}
+
+// CodeFilter rejects short codes that are reserved or offensive.
+type CodeFilter interface {
+	Check(code string) error
+}
+
+// nextShortCode derives a code from the row ID, falling back to random codes
+// when that collides with a custom alias on the same domain or is rejected
+// by the code filter.
+func (s *service) nextShortCode(domainID *int64, id int64) (string, error) {
+	code := encodeBase62(id)
+	for attempt := 0; attempt < 10; attempt++ {
+		if s.codeFilter.Check(code) != nil {
+			code = generateShortCode(7)
+			continue
+		}
+		existing, err := s.repo.GetByShortCode(domainID, code)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return code, nil
+		}
+		code = generateShortCode(7)
+	}
+	return "", errors.New("failed to generate a unique short code")
+}
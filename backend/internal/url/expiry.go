@@ -0,0 +1,89 @@
+package url
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxExpiredMessageLength = 500
+	// maxExtendDays bounds a single extension to about ten years.
+	maxExtendDays = 3650
+)
+
+type ExpirySettings struct {
+	ExpiresAt       *time.Time
+	ExpiredBehavior string
+	FallbackURL     string
+	ExpiredMessage  string
+}
+
+// normalize fills in the default behavior and drops settings that the
+// chosen behavior does not use.
+func (e *ExpirySettings) normalize() error {
+	if e.ExpiredBehavior == "" {
+		e.ExpiredBehavior = ExpiredNotFound
+	}
+
+	switch e.ExpiredBehavior {
+	case ExpiredNotFound, ExpiredGone:
+		e.FallbackURL = ""
+		e.ExpiredMessage = ""
+	case ExpiredFallback:
+		if e.FallbackURL == "" {
+			return errors.New("fallback_url is required for the fallback behavior")
+		}
+		if err := validateURL(e.FallbackURL); err != nil {
+			return err
+		}
+		e.ExpiredMessage = ""
+	case ExpiredPage:
+		if utf8.RuneCountInString(e.ExpiredMessage) > maxExpiredMessageLength {
+			return fmt.Errorf("expired_message too long (max %d characters)", maxExpiredMessageLength)
+		}
+		e.FallbackURL = ""
+	default:
+		return errors.New("expired_behavior must be one of not_found, fallback, page, gone")
+	}
+
+	return nil
+}
+
+// RenewExpiryInput describes how to change a link's expiry. Exactly one of
+// Permanent, ExtendDays or ExpiresAt should be set.
+type RenewExpiryInput struct {
+	ExpiresAt  *time.Time
+	ExtendDays int
+	Permanent  bool
+}
+
+// resolve computes the new expiry date. Extending starts from the current
+// expiry, or from now if the link has already expired.
+func (in RenewExpiryInput) resolve(u *URL, now time.Time) (*time.Time, error) {
+	switch {
+	case in.Permanent:
+		return nil, nil
+	case in.ExtendDays != 0:
+		if in.ExtendDays < 0 || in.ExtendDays > maxExtendDays {
+			return nil, fmt.Errorf("extend_days must be between 1 and %d", maxExtendDays)
+		}
+		if u.ExpiresAt == nil {
+			return nil, errors.New("link does not expire")
+		}
+		from := *u.ExpiresAt
+		if from.Before(now) {
+			from = now
+		}
+		expiresAt := from.AddDate(0, 0, in.ExtendDays)
+		return &expiresAt, nil
+	case in.ExpiresAt != nil:
+		if in.ExpiresAt.Before(now) {
+			return nil, errors.New("expiration date must be in the future")
+		}
+		return in.ExpiresAt, nil
+	default:
+		return nil, errors.New("one of expires_at, extend_days or permanent is required")
+	}
+}
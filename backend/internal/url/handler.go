@@ -1,11 +1,17 @@
 package url
 
 import (
+	"errors"
+	"log"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"url-shortener/internal/domain"
+	"url-shortener/internal/qr"
+	"url-shortener/internal/workspace"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,13 +24,47 @@ func NewHandler(service Service) *Handler {
 }
 
 type createURLRequest struct {
+	OriginalURL     string        `json:"original_url"`
+	ExpiresAt       *time.Time    `json:"expires_at"`
+	ExpiredBehavior string        `json:"expired_behavior"`
+	FallbackURL     string        `json:"fallback_url"`
+	ExpiredMessage  string        `json:"expired_message"`
+	RedirectStatus  int           `json:"redirect_status"`
+	DomainID        *int64        `json:"domain_id"`
+	WorkspaceID     *int64        `json:"workspace_id"`
+	Alias           string        `json:"alias"`
+	Tags            []string      `json:"tags"`
+	QRStyle         *QRStyleInput `json:"qr_style"`
+}
+
+type tagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type redirectStatusRequest struct {
+	RedirectStatus int `json:"redirect_status"`
+}
+
+type destinationRequest struct {
 	OriginalURL string `json:"original_url"`
-	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type renewExpiryRequest struct {
+	ExpiresAt  *time.Time `json:"expires_at"`
+	ExtendDays int        `json:"extend_days"`
+	Permanent  bool       `json:"permanent"`
+}
+
+type expiredBehaviorRequest struct {
+	ExpiredBehavior string `json:"expired_behavior"`
+	FallbackURL     string `json:"fallback_url"`
+	ExpiredMessage  string `json:"expired_message"`
 }
 
 type createURLResponse struct {
 	ShortURL string `json:"short_url"`
 	QRURL    string `json:"qr_url"`
+	QRStatus string `json:"qr_status"`
 }
 
 // POST /api/urls
@@ -41,18 +81,64 @@ func (h *Handler) CreateShortURL(c *gin.Context) {
 		return
 	}
 
-	shortCode, qrURL, err := h.service.CreateShortURL(userID.(int64), req.OriginalURL, req.ExpiresAt)
+	u, err := h.service.CreateShortURL(userID.(int64), CreateURLInput{
+		OriginalURL:    req.OriginalURL,
+		RedirectStatus: req.RedirectStatus,
+		DomainID:       req.DomainID,
+		WorkspaceID:    req.WorkspaceID,
+		Alias:          req.Alias,
+		Tags:           req.Tags,
+		QRStyle:        req.QRStyle,
+		ExpirySettings: ExpirySettings{
+			ExpiresAt:       req.ExpiresAt,
+			ExpiredBehavior: req.ExpiredBehavior,
+			FallbackURL:     req.FallbackURL,
+			ExpiredMessage:  req.ExpiredMessage,
+		},
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, err, http.StatusBadRequest)
 		return
 	}
 
 	c.JSON(http.StatusOK, createURLResponse{
-		ShortURL: os.Getenv("FRONTEND_URL") + "/l/" + shortCode,
-		QRURL:    qrURL,
+		ShortURL: u.ShortURL(),
+		QRURL:    u.QRImageURL(),
+		QRStatus: u.QRStatus,
 	})
 }
 
+// writeError maps service errors to HTTP statuses, falling back to status
+// for anything it does not recognise.
+func writeError(c *gin.Context, err error, status int) {
+	switch {
+	case errors.Is(err, ErrURLNotFound),
+		errors.Is(err, workspace.ErrWorkspaceNotFound),
+		errors.Is(err, domain.ErrDomainNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, qr.ErrLogoUnavailable):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, ErrDestinationLocked), errors.Is(err, ErrRedirectCached), errors.Is(err, ErrAliasTaken):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// workspaceParam reads the optional workspace_id query parameter.
+func workspaceParam(c *gin.Context) (*int64, error) {
+	raw := c.Query("workspace_id")
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid workspace_id")
+	}
+	return &id, nil
+}
+
 // GET /:code
 func (h *Handler) Redirect(c *gin.Context) {
 	shortCode := c.Param("code")
@@ -61,37 +147,78 @@ func (h *Handler) Redirect(c *gin.Context) {
 		return
 	}
 
-	originalURL, err := h.service.GetOriginalURL(shortCode)
-	if err != nil {
+	now := time.Now()
+	u, err := h.service.GetOriginalURL(c.Request.Host, shortCode, clickEvent(c, now))
+	switch {
+	case errors.Is(err, ErrURLDisabled):
+		renderPage(c, http.StatusForbidden, pausedPage, shortCode)
+		return
+	case errors.Is(err, ErrURLExpired):
+		h.serveExpired(c, u)
+		return
+	case err != nil:
 		c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
 		return
 	}
 
-	c.Redirect(http.StatusFound, originalURL)
+	setRedirectCacheHeaders(c, u, now)
+	c.Redirect(u.RedirectStatus, u.OriginalURL)
+}
+
+func (h *Handler) serveExpired(c *gin.Context, u *URL) {
+	switch u.ExpiredBehavior {
+	case ExpiredFallback:
+		c.Header("Cache-Control", "no-store")
+		c.Redirect(http.StatusFound, u.FallbackURL)
+	case ExpiredPage:
+		renderPage(c, http.StatusGone, expiredPage, u)
+	case ExpiredGone:
+		c.JSON(http.StatusGone, gin.H{"error": ErrURLExpired.Error()})
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+	}
 }
 
-// GET /api/urls?user_id=1
+// GET /api/urls?workspace_id=1
 func (h *Handler) ListURLs(c *gin.Context) {
-	userIDStr := c.Query("user_id")
-	userID, _ := strconv.ParseInt(userIDStr, 10, 64)
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
 
-	urls, err := h.service.ListURLs(userID)
+	workspaceID, err := workspaceParam(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	urls, err := h.service.ListURLs(userID.(int64), workspaceID)
+	if err != nil {
+		writeError(c, err, http.StatusInternalServerError)
 		return
 	}
 
 	c.JSON(http.StatusOK, urls)
 }
 
-// GET /api/urls/stats?user_id=1
+// GET /api/urls/stats?workspace_id=1
 func (h *Handler) UserStats(c *gin.Context) {
-	userIDStr := c.Query("user_id")
-	userID, _ := strconv.ParseInt(userIDStr, 10, 64)
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	workspaceID, err := workspaceParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	stats, err := h.service.GetUserStats(userID)
+	stats, err := h.service.GetUserStats(userID.(int64), workspaceID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -106,10 +233,372 @@ func (h *Handler) DeleteURL(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.DeleteURL(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := h.service.DeleteURL(userID.(int64), id); err != nil {
+		writeError(c, err, http.StatusInternalServerError)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "URL deleted"})
-}
\ No newline at end of file
+}
+
+// POST /api/urls/:id/disable
+func (h *Handler) DisableURL(c *gin.Context) {
+	h.setEnabled(c, false)
+}
+
+// POST /api/urls/:id/enable
+func (h *Handler) EnableURL(c *gin.Context) {
+	h.setEnabled(c, true)
+}
+
+func (h *Handler) setEnabled(c *gin.Context, enabled bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := h.service.SetEnabled(userID.(int64), id, enabled); err != nil {
+		writeError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "enabled": enabled})
+}
+
+// PUT /api/urls/:id/expired-behavior
+func (h *Handler) UpdateExpiredBehavior(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req expiredBehaviorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err = h.service.UpdateExpiredBehavior(userID.(int64), id, ExpirySettings{
+		ExpiredBehavior: req.ExpiredBehavior,
+		FallbackURL:     req.FallbackURL,
+		ExpiredMessage:  req.ExpiredMessage,
+	})
+	if err != nil {
+		writeError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Expired behavior updated"})
+}
+
+// PUT /api/urls/:id/expiry
+func (h *Handler) RenewExpiry(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req renewExpiryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	u, err := h.service.RenewExpiry(userID.(int64), id, RenewExpiryInput{
+		ExpiresAt:  req.ExpiresAt,
+		ExtendDays: req.ExtendDays,
+		Permanent:  req.Permanent,
+	})
+	if err != nil {
+		writeError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": u.ID, "expires_at": u.ExpiresAt})
+}
+
+// PUT /api/urls/:id/redirect
+func (h *Handler) UpdateRedirectStatus(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req redirectStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := h.service.UpdateRedirectStatus(userID.(int64), id, req.RedirectStatus); err != nil {
+		writeError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "redirect_status": req.RedirectStatus})
+}
+
+// PUT /api/urls/:id/destination
+func (h *Handler) UpdateDestination(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req destinationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := h.service.UpdateDestination(userID.(int64), id, req.OriginalURL); err != nil {
+		writeError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "original_url": req.OriginalURL})
+}
+
+// PUT /api/urls/:id/tags
+func (h *Handler) UpdateTags(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req tagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := h.service.UpdateTags(userID.(int64), id, req.Tags); err != nil {
+		writeError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tags updated"})
+}
+
+// POST /api/urls/:id/qr
+func (h *Handler) RegenerateQR(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req QRStyleInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	u, err := h.service.RegenerateQR(userID.(int64), id, &req)
+	if err != nil {
+		writeError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"qr_url":    u.QRImageURL(),
+		"qr_style":  u.QRStyle,
+		"qr_status": u.QRStatus,
+	})
+}
+
+// qrOptions reads the format, dpi and width_mm query parameters.
+func qrOptions(c *gin.Context) (qr.Options, error) {
+	opts := qr.Options{Format: strings.ToLower(c.Query("format"))}
+	var err error
+	if raw := c.Query("dpi"); raw != "" {
+		if opts.DPI, err = strconv.Atoi(raw); err != nil {
+			return opts, errors.New("invalid dpi")
+		}
+	}
+	if raw := c.Query("width_mm"); raw != "" {
+		if opts.WidthMM, err = strconv.ParseFloat(raw, 64); err != nil {
+			return opts, errors.New("invalid width_mm")
+		}
+	}
+	return opts, nil
+}
+
+// writeQR sends a rendered QR code, answering 304 when the client already
+// has this rendering.
+func writeQR(c *gin.Context, out *qr.Output, cacheControl, disposition string) {
+	c.Header("ETag", out.ETag)
+	c.Header("Cache-Control", cacheControl)
+	if disposition != "" {
+		c.Header("Content-Disposition", disposition)
+	}
+	if match := c.GetHeader("If-None-Match"); match != "" && (match == out.ETag || match == "*") {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, out.ContentType, out.Data)
+}
+
+// GET /api/urls/:id/qr?format=png|svg|pdf|eps&dpi=&width_mm=
+func (h *Handler) DownloadQR(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	opts, err := qrOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	out, filename, err := h.service.RenderQR(userID.(int64), id, opts)
+	if err != nil {
+		writeError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	writeQR(c, out, "private, no-cache", `attachment; filename="`+filename+`"`)
+}
+
+// GET /api/urls/:id/nfc?format=hex|bin
+func (h *Handler) ExportNFC(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "hex")
+	if format != "hex" && format != "bin" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be hex or bin"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	tag, err := h.service.NFCTag(userID.(int64), id)
+	if err != nil {
+		writeError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if format == "bin" {
+		c.Header("Content-Disposition", `attachment; filename="`+tag.Filename+`"`)
+		c.Data(http.StatusOK, "application/octet-stream", tag.NDEF)
+		return
+	}
+	c.JSON(http.StatusOK, tag)
+}
+
+// POST /api/urls/qr-batch
+func (h *Handler) BatchQR(c *gin.Context) {
+	var req QRBatchInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	batch, err := h.service.BatchQR(userID.(int64), req)
+	if err != nil {
+		writeError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	// The file is streamed, so a failure part way through can only cut the
+	// download short.
+	c.Header("Content-Disposition", `attachment; filename="`+batch.Filename+`"`)
+	c.Header("Content-Type", batch.ContentType)
+	c.Status(http.StatusOK)
+	if err := batch.Write(c.Request.Context(), c.Writer); err != nil {
+		log.Printf("❌ QR batch download failed: %v", err)
+	}
+}
+
+// GET /:code/qr?format=png|svg|pdf|eps
+func (h *Handler) PublicQR(c *gin.Context) {
+	// Anyone can call this, so only the format is taken: the size comes
+	// from the link's style and each link has a handful of renderings to
+	// cache. Print sizes are in the authenticated download.
+	opts := qr.Options{Format: strings.ToLower(c.Query("format"))}
+
+	out, err := h.service.PublicQR(c.Request.Host, c.Param("code"), opts)
+	if err != nil {
+		writeError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	// Styles can change, so caches revalidate against the ETag after
+	// qrCacheMaxAge.
+	writeQR(c, out, "public, max-age="+strconv.FormatInt(int64(qrCacheMaxAge()/time.Second), 10), "")
+}
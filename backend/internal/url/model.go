@@ -1,13 +1,118 @@
 package url
 
-import "time"
+import (
+	"os"
+	"time"
+	"url-shortener/internal/click"
+	"url-shortener/internal/qr"
+)
 
 type URL struct {
-	ID          int64
-	UserID      int64
-	OriginalURL string
-	ShortCode   string
-	QRURL       string
-	CreatedAt   time.Time
-	ExpiresAt   time.Time
+	ID              int64
+	UserID          int64
+	OriginalURL     string
+	ShortCode       string
+	QRURL           string
+	CreatedAt       time.Time
+	ExpiresAt       *time.Time
+	Enabled         bool
+	ExpiredBehavior string
+	FallbackURL     string
+	ExpiredMessage  string
+	RedirectStatus  int
+	DomainID        *int64
+	// Domain is the custom hostname the link lives on, empty for the
+	// default host.
+	Domain      string
+	WorkspaceID *int64
+	Tags        Tags
+	QRStyle     qr.Style
+	// QRStatus tracks the background upload of the QR image.
+	QRStatus string
+	// PermanentServedAt is when the link last answered with a 301/308,
+	// which browsers may keep for permanentCacheMaxAge.
+	PermanentServedAt *time.Time
 }
+
+const (
+	QRStatusPending = "pending"
+	QRStatusReady   = "ready"
+	QRStatusFailed  = "failed"
+)
+
+// PendingQR is a link waiting for its QR image upload. Version changes with
+// every restyle, so a slow upload of an old style cannot be marked ready.
+type PendingQR struct {
+	ID       int64
+	Attempts int
+	Version  int
+}
+
+// BuildQRURL returns the public on-demand QR image URL for a code. Custom
+// domains serve it themselves; default-host links use the API's own URL.
+func BuildQRURL(domain, shortCode string) string {
+	if domain != "" {
+		return "https://" + domain + "/" + shortCode + "/qr"
+	}
+	return PublicAPIURL() + "/" + shortCode + "/qr"
+}
+
+// PublicAPIURL is the API's own public base URL, set with PUBLIC_API_URL.
+func PublicAPIURL() string {
+	if baseURL := os.Getenv("PUBLIC_API_URL"); baseURL != "" {
+		return baseURL
+	}
+	return "http://localhost:8080"
+}
+
+// QRImageURL is the stored QR image once it is uploaded, else the on-demand
+// endpoint.
+func (u *URL) QRImageURL() string {
+	if u.QRStatus == QRStatusReady && u.QRURL != "" {
+		return u.QRURL
+	}
+	return BuildQRURL(u.Domain, u.ShortCode)
+}
+
+// BuildShortURL returns the public short link for a code. Links on a custom
+// domain are served directly by the API at https://<domain>/<code>.
+func BuildShortURL(domain, shortCode string) string {
+	if domain != "" {
+		return "https://" + domain + "/" + shortCode
+	}
+	baseURL := os.Getenv("FRONTEND_URL")
+	if baseURL == "" {
+		baseURL = "https://shorty-black.vercel.app"
+	}
+	return baseURL + "/l/" + shortCode
+}
+
+func (u *URL) ShortURL() string {
+	return BuildShortURL(u.Domain, u.ShortCode)
+}
+
+// QRPayloadURL is the short link encoded in the link's QR code. It carries
+// a source marker so scans can be told apart from clicks on the shared link.
+func (u *URL) QRPayloadURL() string {
+	return u.ShortURL() + "?" + SourceParam + "=" + click.SourceQR
+}
+
+// NFCPayloadURL is the short link written to NFC tags, marked like
+// QRPayloadURL so taps are counted on their own.
+func (u *URL) NFCPayloadURL() string {
+	return u.ShortURL() + "?" + SourceParam + "=" + click.SourceNFC
+}
+
+// IsExpired reports whether the link has an expiry date that has passed.
+// Links without an expiry date never expire.
+func (u *URL) IsExpired(now time.Time) bool {
+	return u.ExpiresAt != nil && u.ExpiresAt.Before(now)
+}
+
+// What Redirect serves once a link has expired.
+const (
+	ExpiredNotFound = "not_found"
+	ExpiredFallback = "fallback"
+	ExpiredPage     = "page"
+	ExpiredGone     = "gone"
+)
@@ -0,0 +1,38 @@
+package url
+
+import (
+	"encoding/hex"
+	"strings"
+	"url-shortener/internal/nfc"
+	"url-shortener/internal/workspace"
+)
+
+// NFCTag is a link encoded for NFC tags: NDEF is the bare NDEF message, TLV
+// the same wrapped for writing straight into Type 2 tag memory.
+type NFCTag struct {
+	URI     string `json:"uri"`
+	Size    int    `json:"size"`
+	NDEFHex string `json:"ndef_hex"`
+	TLVHex  string `json:"tlv_hex"`
+
+	NDEF     []byte `json:"-"`
+	Filename string `json:"-"`
+}
+
+func (s *service) NFCTag(userID, id int64) (*NFCTag, error) {
+	u, err := s.getAuthorized(userID, id, workspace.PermView)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := u.NFCPayloadURL()
+	msg := nfc.URIMessage(uri)
+	return &NFCTag{
+		URI:      uri,
+		Size:     len(msg),
+		NDEFHex:  strings.ToUpper(hex.EncodeToString(msg)),
+		TLVHex:   strings.ToUpper(hex.EncodeToString(nfc.TLV(msg))),
+		NDEF:     msg,
+		Filename: qrPublicID(u) + ".ndef",
+	}, nil
+}
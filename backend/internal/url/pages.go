@@ -0,0 +1,64 @@
+package url
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/gin-gonic/gin"
+)
+
+var pausedPage = template.Must(template.New("paused").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Link paused</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; background: #f5f6fa; color: #2d3436; display: flex; align-items: center; justify-content: center; min-height: 100vh; margin: 0; }
+main { background: #fff; border-radius: 12px; box-shadow: 0 4px 24px rgba(0,0,0,.08); padding: 40px; max-width: 420px; text-align: center; }
+h1 { font-size: 1.5rem; margin: 0 0 12px; }
+p { margin: 0; color: #636e72; }
+</style>
+</head>
+<body>
+<main>
+<h1>This link is paused</h1>
+<p>The owner of <strong>/{{.}}</strong> has temporarily disabled it. Please check back later.</p>
+</main>
+</body>
+</html>
+`))
+
+var expiredPage = template.Must(template.New("expired").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Link expired</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; background: #f5f6fa; color: #2d3436; display: flex; align-items: center; justify-content: center; min-height: 100vh; margin: 0; }
+main { background: #fff; border-radius: 12px; box-shadow: 0 4px 24px rgba(0,0,0,.08); padding: 40px; max-width: 420px; text-align: center; }
+h1 { font-size: 1.5rem; margin: 0 0 12px; }
+p { margin: 0; color: #636e72; white-space: pre-line; }
+</style>
+</head>
+<body>
+<main>
+<h1>This link has expired</h1>
+{{if .ExpiredMessage}}<p>{{.ExpiredMessage}}</p>{{else}}<p>The link <strong>/{{.ShortCode}}</strong> is no longer available.</p>{{end}}
+</main>
+</body>
+</html>
+`))
+
+func renderPage(c *gin.Context, status int, tmpl *template.Template, data any) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		c.String(status, err.Error())
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,58 @@
+package url
+
+import (
+	"os"
+	"time"
+	"url-shortener/internal/qr"
+)
+
+const defaultQRCacheMaxAge = time.Hour
+
+// qrCacheMaxAge is how long shared caches may keep a public QR image before
+// revalidating. Configured with QR_CACHE_MAX_AGE.
+func qrCacheMaxAge() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("QR_CACHE_MAX_AGE")); err == nil && d >= 0 {
+		return d
+	}
+	return defaultQRCacheMaxAge
+}
+
+// QRStyleInput holds the QR settings a request wants to change; nil fields
+// keep the current (or default) value.
+type QRStyleInput struct {
+	Size            *int    `json:"size"`
+	ErrorCorrection *string `json:"error_correction"`
+	QuietZone       *int    `json:"quiet_zone"`
+	Foreground      *string `json:"foreground"`
+	Background      *string `json:"background"`
+	LogoURL         *string `json:"logo_url"`
+}
+
+// apply overlays the input on base and validates the result.
+func (in *QRStyleInput) apply(base qr.Style) (qr.Style, error) {
+	style := base
+	if in != nil {
+		if in.Size != nil {
+			style.Size = *in.Size
+		}
+		if in.ErrorCorrection != nil {
+			style.ErrorCorrection = *in.ErrorCorrection
+		}
+		if in.QuietZone != nil {
+			style.QuietZone = *in.QuietZone
+		}
+		if in.Foreground != nil {
+			style.Foreground = *in.Foreground
+		}
+		if in.Background != nil {
+			style.Background = *in.Background
+		}
+		if in.LogoURL != nil {
+			style.LogoURL = *in.LogoURL
+		}
+	}
+	if err := style.Normalize(); err != nil {
+		return qr.Style{}, err
+	}
+	return style, nil
+}
@@ -0,0 +1,246 @@
+package url
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+	"url-shortener/internal/qr"
+	"url-shortener/internal/storage"
+)
+
+// QRQueue accepts links whose QR image needs uploading.
+type QRQueue interface {
+	Enqueue(id int64)
+}
+
+type QRWorkerConfig struct {
+	// PollInterval is how often pending images are picked up when nothing
+	// was enqueued, e.g. retries and work left over from a restart.
+	PollInterval time.Duration
+	// MaxAttempts marks an image failed after this many tries.
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// BreakerThreshold consecutive storage failures stop uploads for
+	// BreakerCooldown.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+var DefaultQRWorkerConfig = QRWorkerConfig{
+	PollInterval:     30 * time.Second,
+	MaxAttempts:      6,
+	BaseBackoff:      5 * time.Second,
+	MaxBackoff:       30 * time.Minute,
+	BreakerThreshold: 5,
+	BreakerCooldown:  time.Minute,
+}
+
+const qrBatchSize = 50
+
+var errBreakerOpen = errors.New("QR storage circuit breaker is open")
+
+// QRWorker renders and uploads QR images in the background so link creation
+// never waits on the storage driver. Failed uploads are retried with
+// exponential backoff; repeated storage failures open a circuit breaker
+// that pauses uploads instead of piling retries onto a broken backend.
+type QRWorker struct {
+	repo     Repository
+	store    storage.Storage
+	renderer *qr.Renderer
+	cfg      QRWorkerConfig
+	breaker  *circuitBreaker
+	wake     chan struct{}
+}
+
+func NewQRWorker(repo Repository, store storage.Storage, renderer *qr.Renderer, cfg QRWorkerConfig) *QRWorker {
+	return &QRWorker{
+		repo:     repo,
+		store:    store,
+		renderer: renderer,
+		cfg:      cfg,
+		breaker:  &circuitBreaker{threshold: cfg.BreakerThreshold, cooldown: cfg.BreakerCooldown},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Enqueue wakes the worker. The link is already marked pending in the
+// database, so a dropped wake-up only delays it until the next poll.
+func (w *QRWorker) Enqueue(id int64) {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *QRWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.RunOnce(ctx); err != nil && !errors.Is(err, errBreakerOpen) {
+			log.Printf("❌ QR worker run failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+	}
+}
+
+// RunOnce uploads every QR image that is due, batch by batch.
+func (w *QRWorker) RunOnce(ctx context.Context) error {
+	for {
+		pending, err := w.repo.ListPendingQR(time.Now(), qrBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list pending QR codes: %w", err)
+		}
+		for _, p := range pending {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !w.breaker.allow() {
+				return errBreakerOpen
+			}
+			err := w.process(ctx, p)
+			w.breaker.done()
+			if err != nil {
+				// The outcome could not be saved, so the same batch would
+				// be listed again; wait for the next run instead.
+				return err
+			}
+		}
+		if len(pending) < qrBatchSize {
+			return nil
+		}
+	}
+}
+
+// process renders and uploads one image. It only fails when the outcome
+// cannot be saved.
+func (w *QRWorker) process(ctx context.Context, p PendingQR) error {
+	u, err := w.repo.GetByID(p.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load URL %d: %w", p.ID, err)
+	}
+	if u == nil {
+		return nil
+	}
+
+	renderCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	out, err := w.renderer.Render(renderCtx, u.QRPayloadURL(), u.QRStyle, qr.Options{Format: qr.FormatPNG})
+	if err != nil {
+		// Rendering problems, e.g. an unreachable logo, are not the
+		// storage's fault and leave the breaker alone.
+		return w.fail(p, fmt.Errorf("failed to render QR code: %w", err))
+	}
+
+	qrURL, err := w.store.Put(renderCtx, QRObjectKey(u), out.Data, out.ContentType)
+	if err != nil {
+		w.breaker.failure()
+		return w.fail(p, fmt.Errorf("failed to upload QR code: %w", err))
+	}
+	w.breaker.success()
+
+	// A restyle while rendering leaves the link pending at a new version,
+	// and the next run uploads the new style over this one.
+	if _, err := w.repo.MarkQRReady(u.ID, p.Version, qrURL); err != nil {
+		return fmt.Errorf("failed to save QR URL for link %d: %w", u.ID, err)
+	}
+	return nil
+}
+
+func (w *QRWorker) fail(p PendingQR, cause error) error {
+	attempts := p.Attempts + 1
+	status := QRStatusPending
+	if attempts >= w.cfg.MaxAttempts {
+		status = QRStatusFailed
+		log.Printf("❌ Giving up on QR code for link %d after %d attempts: %v", p.ID, attempts, cause)
+	}
+
+	next := time.Now().Add(w.backoff(attempts))
+	if _, err := w.repo.MarkQRFailure(p.ID, p.Version, status, attempts, next, cause.Error()); err != nil {
+		return fmt.Errorf("failed to record QR failure for link %d: %w", p.ID, err)
+	}
+	return nil
+}
+
+// backoff doubles the delay per attempt up to MaxBackoff, with up to 20%
+// jitter so a batch of failures does not retry in lockstep.
+func (w *QRWorker) backoff(attempts int) time.Duration {
+	d := w.cfg.BaseBackoff
+	for i := 1; i < attempts && d < w.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > w.cfg.MaxBackoff {
+		d = w.cfg.MaxBackoff
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+// circuitBreaker opens after threshold consecutive failures. Once the
+// cooldown passes it lets a single trial through: success closes it,
+// failure opens it again.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.trial {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// done ends a trial that never reached storage, e.g. a render failure, so
+// the next upload can try again.
+func (b *circuitBreaker) done() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+}
+
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.failures >= b.threshold {
+		if b.failures == b.threshold {
+			log.Printf("⚠️ QR storage failing, pausing uploads for %s", b.cooldown)
+		}
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
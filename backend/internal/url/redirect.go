@@ -0,0 +1,117 @@
+package url
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+	"url-shortener/internal/click"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrDestinationLocked = errors.New("links with a permanent redirect (301/308) cannot change destination; switch to 302 or 307 once browsers' cached copies have expired")
+	// ErrRedirectCached refuses changes that browsers holding a cached
+	// permanent redirect would not see.
+	ErrRedirectCached = errors.New("this link was recently served with a permanent redirect (301/308) that browsers may still have cached")
+)
+
+const defaultPermanentCacheMaxAge = 24 * time.Hour
+
+// permanentServedResolution is how often PermanentServedAt is refreshed
+// while a link keeps serving permanent redirects; the lock allows for it.
+const permanentServedResolution = time.Minute
+
+// SourceParam is the query parameter carrying the click source marker. It
+// is only read for attribution; Redirect never forwards it.
+const SourceParam = "s"
+
+// clickEvent captures the visit for analytics. The source marker becomes
+// the event's source and is left out of the recorded query parameters;
+// unknown or missing markers count as a plain link click.
+func clickEvent(c *gin.Context, now time.Time) click.ClickEvent {
+	query := c.Request.URL.Query()
+	source := query.Get(SourceParam)
+	if !click.ValidSource(source) {
+		source = click.SourceLink
+	}
+	query.Del(SourceParam)
+
+	return click.ClickEvent{
+		Source:         source,
+		Timestamp:      now,
+		Referrer:       c.Request.Referer(),
+		UserAgent:      c.Request.UserAgent(),
+		IP:             c.ClientIP(),
+		AcceptLanguage: c.GetHeader("Accept-Language"),
+		QueryParams:    query,
+	}
+}
+
+func validateRedirectStatus(status int) error {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return nil
+	}
+	return errors.New("redirect_status must be one of 301, 302, 307, 308")
+}
+
+func isPermanentRedirect(status int) bool {
+	return status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
+}
+
+// permanentCacheMaxAge bounds how long clients may cache a 301/308. Browsers
+// cache permanent redirects indefinitely unless told otherwise, so we always
+// send an explicit lifetime. Configured with REDIRECT_CACHE_MAX_AGE.
+func permanentCacheMaxAge() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("REDIRECT_CACHE_MAX_AGE")); err == nil && d >= 0 {
+		return d
+	}
+	return defaultPermanentCacheMaxAge
+}
+
+// redirectCachedUntil is when the last permanent redirect served for u
+// expires from browser caches.
+func (u *URL) redirectCachedUntil() time.Time {
+	if u.PermanentServedAt == nil {
+		return time.Time{}
+	}
+	return u.PermanentServedAt.Add(permanentCacheMaxAge() + permanentServedResolution)
+}
+
+// checkRedirectCache fails with ErrRedirectCached while browsers may still
+// follow a cached permanent redirect for u.
+func checkRedirectCache(u *URL, now time.Time) error {
+	if until := u.redirectCachedUntil(); now.Before(until) {
+		return fmt.Errorf("%w; try again after %s", ErrRedirectCached, until.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// setRedirectCacheHeaders lets permanent redirects be cached, but never past
+// the link's expiry. Temporary redirects are never cached so every visit
+// reaches us, is counted, and sees destination changes immediately.
+func setRedirectCacheHeaders(c *gin.Context, u *URL, now time.Time) {
+	maxAge := time.Duration(0)
+	if isPermanentRedirect(u.RedirectStatus) {
+		maxAge = permanentCacheMaxAge()
+		if u.ExpiresAt != nil {
+			if untilExpiry := u.ExpiresAt.Sub(now); untilExpiry < maxAge {
+				maxAge = untilExpiry
+			}
+		}
+	}
+
+	seconds := int64(maxAge / time.Second)
+	if seconds <= 0 {
+		c.Header("Cache-Control", "private, no-cache, no-store, must-revalidate")
+		c.Header("Expires", time.Unix(0, 0).UTC().Format(http.TimeFormat))
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age="+strconv.FormatInt(seconds, 10))
+	c.Header("Expires", now.Add(time.Duration(seconds)*time.Second).UTC().Format(http.TimeFormat))
+}
@@ -2,20 +2,70 @@ package url
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"time"
-	"os"
+	"url-shortener/internal/qr"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type Repository interface {
-	Create(userID int64, originalURL, shortCode, qrURL string, expiresAt time.Time) (int64, error)
-	GetByShortCode(shortCode string) (*URL, error)
+	Create(u *URL) (int64, error)
+	GetByShortCode(domainID *int64, shortCode string) (*URL, error)
 	GetByID(id int64) (*URL, error)
-	FindExistingURL(userID int64, originalURL string) (*URL, error) 
-	List(userID int64) ([]*URL, error)
-	GetUserStats(userID int64) ([]*URLStats, error)
+	FindExistingURL(userID int64, workspaceID, domainID *int64, originalURL string) (*URL, error)
+	List(userID int64, workspaceID *int64) ([]*URL, error)
+	ListByTag(userID int64, workspaceID *int64, tag string) ([]*URL, error)
+	GetUserStats(userID int64, workspaceID *int64) ([]*URLStats, error)
 	DeleteByID(id int64) error
-	CountURLsCreatedToday(userID int64) (int, error)
-	UpdateShortCodeAndQR(id int64, shortCode, qrURL string) error
+	CountURLsCreatedToday(userID int64, workspaceID *int64) (int, error)
+	NextID() (int64, error)
+	SetEnabled(id int64, enabled bool) error
+	UpdateExpiredBehavior(id int64, behavior, fallbackURL, message string) error
+	UpdateExpiry(id int64, expiresAt *time.Time) error
+	ListExpiringBefore(before time.Time) ([]*URL, error)
+	MarkExpiryNotified(id int64) error
+	UpdateRedirectStatus(id int64, status int) error
+	UpdateDestination(id int64, originalURL string) error
+	MarkPermanentServed(id int64, at time.Time) error
+	UpdateTags(id int64, tags Tags) error
+	ListExpiredBefore(before time.Time, afterID int64, limit int) ([]*URL, error)
+	Archive(id int64) error
+	ListByShortCodes(codes []string) ([]*URL, error)
+	UpdateQRStyle(id int64, style qr.Style) error
+	ListPendingQR(now time.Time, limit int) ([]PendingQR, error)
+	// MarkQRReady and MarkQRFailure only apply while the link is pending
+	// at version; they report whether it was.
+	MarkQRReady(id int64, version int, qrURL string) (bool, error)
+	MarkQRFailure(id int64, version int, status string, attempts int, nextAttempt time.Time, message string) (bool, error)
+}
+
+// selectURL joins the link's custom domain so callers can build its short URL.
+const selectURL = `
+	SELECT u.id, u.user_id, u.original_url, u.short_code, u.qr_url, u.created_at, u.expires_at, u.enabled,
+		u.expired_behavior, u.fallback_url, u.expired_message, u.redirect_status, u.domain_id, COALESCE(d.hostname, ''), u.workspace_id,
+		to_json(u.tags), u.qr_style, u.qr_status, u.permanent_served_at
+	FROM urls u
+	LEFT JOIN domains d ON d.id = u.domain_id`
+
+// ownerScope matches a user's personal links ($1, with $2 NULL) or every link
+// in workspace $2.
+const ownerScope = "(($2::int IS NULL AND u.user_id = $1 AND u.workspace_id IS NULL) OR u.workspace_id = $2)"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanURL(row rowScanner) (*URL, error) {
+	u := &URL{}
+	err := row.Scan(&u.ID, &u.UserID, &u.OriginalURL, &u.ShortCode, &u.QRURL, &u.CreatedAt, &u.ExpiresAt, &u.Enabled,
+		&u.ExpiredBehavior, &u.FallbackURL, &u.ExpiredMessage, &u.RedirectStatus, &u.DomainID, &u.Domain, &u.WorkspaceID, &u.Tags, &u.QRStyle, &u.QRStatus,
+		&u.PermanentServedAt)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
 }
 
 type repository struct {
@@ -26,22 +76,25 @@ func NewRepository(db *sql.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) Create(userID int64, originalURL, shortCode, qrURL string, expiresAt time.Time) (int64, error) {
+func (r *repository) Create(u *URL) (int64, error) {
 	var id int64
-	err := r.db.QueryRow(
-		"INSERT INTO urls (user_id, original_url, short_code, qr_url, expires_at) VALUES ($1,$2,$3,$4,$5) RETURNING id",
-		userID, originalURL, shortCode, qrURL, expiresAt,
+	err := r.db.QueryRow(`
+		INSERT INTO urls (id, user_id, original_url, short_code, qr_url, expires_at, expired_behavior, fallback_url, expired_message,
+			redirect_status, domain_id, workspace_id, tags, created_at, qr_style, qr_status)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,
+			ARRAY(SELECT json_array_elements_text($13::json)), COALESCE($14, CURRENT_TIMESTAMP), $15, 'pending') RETURNING id`,
+		u.ID, u.UserID, u.OriginalURL, u.ShortCode, u.QRURL, u.ExpiresAt, u.ExpiredBehavior, u.FallbackURL, u.ExpiredMessage,
+		u.RedirectStatus, u.DomainID, u.WorkspaceID, u.Tags, nullTime(u.CreatedAt), u.QRStyle,
 	).Scan(&id)
 	return id, err
 }
 
-func (r *repository) GetByShortCode(shortCode string) (*URL, error) {
-	row := r.db.QueryRow(
-		"SELECT id, user_id, original_url, short_code, qr_url, created_at, expires_at FROM urls WHERE short_code=$1",
-		shortCode,
-	)
-	u := &URL{}
-	if err := row.Scan(&u.ID, &u.UserID, &u.OriginalURL, &u.ShortCode, &u.QRURL, &u.CreatedAt, &u.ExpiresAt); err != nil {
+// GetByShortCode looks a code up within one domain; a nil domainID means the
+// default short link host.
+func (r *repository) GetByShortCode(domainID *int64, shortCode string) (*URL, error) {
+	row := r.db.QueryRow(selectURL+" WHERE u.short_code=$1 AND u.domain_id IS NOT DISTINCT FROM $2", shortCode, domainID)
+	u, err := scanURL(row)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -51,12 +104,9 @@ func (r *repository) GetByShortCode(shortCode string) (*URL, error) {
 }
 
 func (r *repository) GetByID(id int64) (*URL, error) {
-	row := r.db.QueryRow(
-		"SELECT id, user_id, original_url, short_code, qr_url, created_at, expires_at FROM urls WHERE id=$1",
-		id,
-	)
-	u := &URL{}
-	if err := row.Scan(&u.ID, &u.UserID, &u.OriginalURL, &u.ShortCode, &u.QRURL, &u.CreatedAt, &u.ExpiresAt); err != nil {
+	row := r.db.QueryRow(selectURL+" WHERE u.id=$1", id)
+	u, err := scanURL(row)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -65,30 +115,26 @@ func (r *repository) GetByID(id int64) (*URL, error) {
 	return u, nil
 }
 
-
-func (r *repository) FindExistingURL(userID int64, originalURL string) (*URL, error) {
-	row := r.db.QueryRow(`
-		SELECT id, user_id, original_url, short_code, qr_url, created_at, expires_at 
-		FROM urls 
-		WHERE user_id = $1 AND original_url = $2
+func (r *repository) FindExistingURL(userID int64, workspaceID, domainID *int64, originalURL string) (*URL, error) {
+	row := r.db.QueryRow(selectURL+`
+		WHERE `+ownerScope+` AND u.original_url = $3 AND u.domain_id IS NOT DISTINCT FROM $4
 		LIMIT 1
-	`, userID, originalURL)
+	`, userID, workspaceID, originalURL, domainID)
 
-	u := &URL{}
-	err := row.Scan(&u.ID, &u.UserID, &u.OriginalURL, &u.ShortCode, &u.QRURL, &u.CreatedAt, &u.ExpiresAt)
+	u, err := scanURL(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil 
+			return nil, nil
 		}
 		return nil, err
 	}
 	return u, nil
 }
 
-func (r *repository) List(userID int64) ([]*URL, error) {
+func (r *repository) List(userID int64, workspaceID *int64) ([]*URL, error) {
 	rows, err := r.db.Query(
-		"SELECT id, user_id, original_url, short_code, qr_url, created_at, expires_at FROM urls WHERE user_id=$1 ORDER BY created_at DESC",
-		userID,
+		selectURL+" WHERE "+ownerScope+" ORDER BY u.created_at DESC",
+		userID, workspaceID,
 	)
 	if err != nil {
 		return nil, err
@@ -97,8 +143,8 @@ func (r *repository) List(userID int64) ([]*URL, error) {
 
 	var urls []*URL
 	for rows.Next() {
-		u := &URL{}
-		if err := rows.Scan(&u.ID, &u.UserID, &u.OriginalURL, &u.ShortCode, &u.QRURL, &u.CreatedAt, &u.ExpiresAt); err != nil {
+		u, err := scanURL(rows)
+		if err != nil {
 			return nil, err
 		}
 		urls = append(urls, u)
@@ -106,13 +152,28 @@ func (r *repository) List(userID int64) ([]*URL, error) {
 	return urls, nil
 }
 
+func (r *repository) ListByTag(userID int64, workspaceID *int64, tag string) ([]*URL, error) {
+	rows, err := r.db.Query(
+		selectURL+" WHERE "+ownerScope+" AND $3 = ANY(u.tags) ORDER BY u.created_at DESC",
+		userID, workspaceID, tag,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-func (r *repository) GetUserStats(userID int64) ([]*URLStats, error) {
-	baseURL := os.Getenv("FRONTEND_URL") + "/l/"
-	if baseURL == "" {
-		baseURL = "https://shorty-black.vercel.app/"
+	var urls []*URL
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
 	}
+	return urls, rows.Err()
+}
 
+func (r *repository) GetUserStats(userID int64, workspaceID *int64) ([]*URLStats, error) {
 	query := `
 		SELECT 
 			u.id,
@@ -121,15 +182,28 @@ func (r *repository) GetUserStats(userID int64) ([]*URLStats, error) {
 			u.qr_url,
 			u.created_at,
 			u.expires_at,
-			COUNT(c.id) as clicks
+			u.enabled,
+			u.expired_behavior,
+			u.fallback_url,
+			u.expired_message,
+			u.redirect_status,
+			COALESCE(d.hostname, ''),
+			u.workspace_id,
+			to_json(u.tags),
+			u.qr_style,
+			u.qr_status,
+			COUNT(c.id) as clicks,
+			COUNT(c.id) FILTER (WHERE c.source = 'qr') as qr_scans,
+			COUNT(c.id) FILTER (WHERE c.source = 'nfc') as nfc_taps
 		FROM urls u
+		LEFT JOIN domains d ON d.id = u.domain_id
 		LEFT JOIN clicks c ON c.url_id = u.id
-		WHERE u.user_id = $1
-		GROUP BY u.id, u.original_url, u.short_code, u.qr_url, u.created_at, u.expires_at
+		WHERE ` + ownerScope + `
+		GROUP BY u.id, d.hostname
 		ORDER BY u.created_at DESC
 	`
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.Query(query, userID, workspaceID)
 	if err != nil {
 		return nil, err
 	}
@@ -138,8 +212,8 @@ func (r *repository) GetUserStats(userID int64) ([]*URLStats, error) {
 	var stats []*URLStats
 	for rows.Next() {
 		var s URLStats
-		var shortCode string
-		var clicks int64
+		var shortCode, domain string
+		var clicks, qrScans, nfcTaps int64
 		if err := rows.Scan(
 			&s.ID,
 			&s.OriginalURL,
@@ -147,40 +221,295 @@ func (r *repository) GetUserStats(userID int64) ([]*URLStats, error) {
 			&s.QRURL,
 			&s.CreatedAt,
 			&s.ExpiresAt,
+			&s.Enabled,
+			&s.ExpiredBehavior,
+			&s.FallbackURL,
+			&s.ExpiredMessage,
+			&s.RedirectStatus,
+			&domain,
+			&s.WorkspaceID,
+			&s.Tags,
+			&s.QRStyle,
+			&s.QRStatus,
 			&clicks,
+			&qrScans,
+			&nfcTaps,
 		); err != nil {
 			return nil, err
 		}
 		s.Clicks = int(clicks)
-		s.ShortURL = baseURL + shortCode
+		s.QRScans = int(qrScans)
+		s.NFCTaps = int(nfcTaps)
+		s.LinkClicks = s.Clicks - s.QRScans - s.NFCTaps
+		s.ShortURL = BuildShortURL(domain, shortCode)
+		if s.QRStatus != QRStatusReady || s.QRURL == "" {
+			s.QRURL = BuildQRURL(domain, shortCode)
+		}
 		stats = append(stats, &s)
 	}
 	return stats, nil
 }
 
 func (r *repository) DeleteByID(id int64) error {
-	
+
 	_, err := r.db.Exec("DELETE FROM clicks WHERE url_id=$1", id)
 	if err != nil {
 		return err
 	}
-	
+
 	_, err = r.db.Exec("DELETE FROM urls WHERE id=$1", id)
 	return err
 }
 
-func (r *repository) CountURLsCreatedToday(userID int64) (int, error) {
+// CountURLsCreatedToday counts against the personal quota, or the shared
+// workspace quota when workspaceID is set.
+func (r *repository) CountURLsCreatedToday(userID int64, workspaceID *int64) (int, error) {
 	var count int
 	err := r.db.QueryRow(
-		"SELECT COUNT(*) FROM urls WHERE user_id=$1 AND created_at >= CURRENT_DATE",
-		userID,
+		"SELECT COUNT(*) FROM urls u WHERE "+ownerScope+" AND u.created_at >= CURRENT_DATE",
+		userID, workspaceID,
 	).Scan(&count)
 	return count, err
 }
-func (r *repository) UpdateShortCodeAndQR(id int64, shortCode, qrURL string) error {
+
+// NextID reserves a link ID so its short code can be derived before the row
+// is inserted.
+func (r *repository) NextID() (int64, error) {
+	var id int64
+	err := r.db.QueryRow("SELECT nextval(pg_get_serial_sequence('urls', 'id'))").Scan(&id)
+	return id, err
+}
+
+func (r *repository) SetEnabled(id int64, enabled bool) error {
+	_, err := r.db.Exec("UPDATE urls SET enabled=$1 WHERE id=$2", enabled, id)
+	return err
+}
+
+func (r *repository) UpdateExpiredBehavior(id int64, behavior, fallbackURL, message string) error {
+	_, err := r.db.Exec(
+		"UPDATE urls SET expired_behavior=$1, fallback_url=$2, expired_message=$3 WHERE id=$4",
+		behavior, fallbackURL, message, id,
+	)
+	return err
+}
+
+// UpdateExpiry also clears expiry_notified_at so a renewed link is warned
+// about again before its new expiry date.
+func (r *repository) UpdateExpiry(id int64, expiresAt *time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE urls SET expires_at=$1, expiry_notified_at=NULL WHERE id=$2",
+		expiresAt, id,
+	)
+	return err
+}
+
+// ListExpiringBefore returns active links that expire between now and before
+// and whose owner has not been notified yet.
+func (r *repository) ListExpiringBefore(before time.Time) ([]*URL, error) {
+	rows, err := r.db.Query(selectURL+`
+		WHERE u.enabled
+		  AND u.expires_at > NOW()
+		  AND u.expires_at <= $1
+		  AND u.expiry_notified_at IS NULL
+		ORDER BY u.expires_at`,
+		before,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []*URL
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+	return urls, rows.Err()
+}
+
+func (r *repository) MarkExpiryNotified(id int64) error {
+	_, err := r.db.Exec("UPDATE urls SET expiry_notified_at=NOW() WHERE id=$1", id)
+	return err
+}
+
+func (r *repository) UpdateRedirectStatus(id int64, status int) error {
+	_, err := r.db.Exec("UPDATE urls SET redirect_status=$1 WHERE id=$2", status, id)
+	return err
+}
+
+func (r *repository) MarkPermanentServed(id int64, at time.Time) error {
+	_, err := r.db.Exec("UPDATE urls SET permanent_served_at=$1 WHERE id=$2", at.UTC(), id)
+	return err
+}
+
+func (r *repository) UpdateDestination(id int64, originalURL string) error {
+	_, err := r.db.Exec("UPDATE urls SET original_url=$1 WHERE id=$2", originalURL, id)
+	return err
+}
+
+func (r *repository) UpdateTags(id int64, tags Tags) error {
 	_, err := r.db.Exec(
-		"UPDATE urls SET short_code=$1, qr_url=$2 WHERE id=$3",
-		shortCode, qrURL, id,
+		"UPDATE urls SET tags=ARRAY(SELECT json_array_elements_text($1::json)) WHERE id=$2",
+		tags, id,
 	)
 	return err
-}
\ No newline at end of file
+}
+
+// ListExpiredBefore returns links with an id above afterID whose expiry
+// passed before the given time, in id order. Links that still serve a
+// fallback or an expiry page when expired are left out.
+func (r *repository) ListExpiredBefore(before time.Time, afterID int64, limit int) ([]*URL, error) {
+	rows, err := r.db.Query(
+		selectURL+" WHERE u.expires_at <= $1 AND u.expired_behavior = '"+ExpiredNotFound+"' AND u.id > $2 ORDER BY u.id LIMIT $3",
+		before, afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []*URL
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+	return urls, rows.Err()
+}
+
+// Archive moves a link into archived_urls, keeping a snapshot of the row and
+// its click count, then deletes it and its clicks.
+func (r *repository) Archive(id int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO archived_urls (id, user_id, workspace_id, short_code, original_url, clicks, data)
+		SELECT u.id, u.user_id, u.workspace_id, u.short_code, u.original_url,
+			(SELECT COUNT(*) FROM clicks c WHERE c.url_id = u.id), to_jsonb(u)
+		FROM urls u WHERE u.id = $1
+		ON CONFLICT (id) DO NOTHING`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM clicks WHERE url_id=$1", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM urls WHERE id=$1", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListByShortCodes returns every link, on any domain, whose code is one of
+// codes.
+func (r *repository) ListByShortCodes(codes []string) ([]*URL, error) {
+	raw, err := json.Marshal(codes)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(
+		selectURL+" WHERE u.short_code IN (SELECT json_array_elements_text($1::json))",
+		string(raw),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []*URL
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+	return urls, rows.Err()
+}
+
+// UpdateQRStyle saves a new style and queues the image for re-upload.
+func (r *repository) UpdateQRStyle(id int64, style qr.Style) error {
+	_, err := r.db.Exec(`
+		UPDATE urls SET qr_style=$1, qr_status='pending', qr_attempts=0, qr_next_attempt_at=NOW(), qr_error='',
+			qr_version=qr_version+1
+		WHERE id=$2`,
+		style, id,
+	)
+	return err
+}
+
+// ListPendingQR returns links whose QR image is due for an upload attempt.
+func (r *repository) ListPendingQR(now time.Time, limit int) ([]PendingQR, error) {
+	rows, err := r.db.Query(`
+		SELECT id, qr_attempts, qr_version FROM urls
+		WHERE qr_status = 'pending' AND qr_next_attempt_at <= $1
+		ORDER BY qr_next_attempt_at
+		LIMIT $2`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []PendingQR
+	for rows.Next() {
+		var p PendingQR
+		if err := rows.Scan(&p.ID, &p.Attempts, &p.Version); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (r *repository) MarkQRReady(id int64, version int, qrURL string) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE urls SET qr_url=$1, qr_status='ready', qr_attempts=0, qr_error=''
+		WHERE id=$2 AND qr_status='pending' AND qr_version=$3`,
+		qrURL, id, version,
+	)
+	return affected(res, err)
+}
+
+func (r *repository) MarkQRFailure(id int64, version int, status string, attempts int, nextAttempt time.Time, message string) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE urls SET qr_status=$1, qr_attempts=$2, qr_next_attempt_at=$3, qr_error=$4
+		WHERE id=$5 AND qr_status='pending' AND qr_version=$6`,
+		status, attempts, nextAttempt, message, id, version,
+	)
+	return affected(res, err)
+}
+
+func affected(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// isUniqueViolation reports whether err is Postgres rejecting a duplicate
+// key.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -1,51 +1,133 @@
 package url
 
 import (
-	"bytes"
 	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
+	"log"
 	"math/big"
+	"net/http"
 	"net/url"
-	"os"
 	"strings"
 	"time"
 	"url-shortener/internal/click"
-
-	"github.com/cloudinary/cloudinary-go/v2"
-	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
-
-	"github.com/skip2/go-qrcode"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/qr"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/workspace"
 )
 
 type Service interface {
-	CreateShortURL(userID int64, originalURL string, expiresAt time.Time) (string, string, error)
-	GetOriginalURL(shortCode string) (string, error)
-	ListURLs(userID int64) ([]*URL, error)
-	GetUserStats(userID int64) ([]*URLStats, error)
-	DeleteURL(id int64) error
-	GetURLByID(id int64) (*URL, error)
+	CreateShortURL(userID int64, in CreateURLInput) (*URL, error)
+	// GetOriginalURL resolves a short code and records the visit, filling in
+	// the link and destination of the click event.
+	GetOriginalURL(host, shortCode string, event click.ClickEvent) (*URL, error)
+	ListURLs(userID int64, workspaceID *int64) ([]*URL, error)
+	GetUserStats(userID int64, workspaceID *int64) ([]*URLStats, error)
+	DeleteURL(userID, id int64) error
+	// DiscardURL removes a link the caller has just created when a later
+	// step that depends on it fails. Unlike DeleteURL it needs no delete
+	// permission, only that the caller created the link.
+	DiscardURL(userID, id int64) error
+	GetURLByID(userID, id int64) (*URL, error)
+	SetEnabled(userID, id int64, enabled bool) error
+	UpdateExpiredBehavior(userID, id int64, settings ExpirySettings) error
+	RenewExpiry(userID, id int64, in RenewExpiryInput) (*URL, error)
+	UpdateRedirectStatus(userID, id int64, status int) error
+	UpdateDestination(userID, id int64, originalURL string) error
+	UpdateTags(userID, id int64, tags []string) error
+	// ImportURL creates a link on behalf of an import job. The importer
+	// charges rows against its own daily allowance, so this skips the daily
+	// quota. It returns the existing link with ErrDuplicateURL when the URL
+	// is already shortened in the same scope.
+	ImportURL(userID int64, in CreateURLInput) (*URL, error)
+	// RegenerateQR redraws a link's QR code with the given changes to its
+	// stored style.
+	RegenerateQR(userID, id int64, style *QRStyleInput) (*URL, error)
+	// RenderQR draws a link's QR code in its stored style in the requested
+	// format, returning the file and its download name.
+	RenderQR(userID, id int64, opts qr.Options) (*qr.Output, string, error)
+	// NFCTag encodes a link as an NDEF message for programming NFC tags.
+	NFCTag(userID, id int64) (*NFCTag, error)
+	PublicQR(host, shortCode string, opts qr.Options) (*qr.Output, error)
+	// BatchQR checks a request for the QR codes of several links at once,
+	// as a ZIP of images or a printable sheet, and returns the download to
+	// render.
+	BatchQR(userID int64, in QRBatchInput) (*QRBatch, error)
+}
+
+type CreateURLInput struct {
+	OriginalURL    string
+	RedirectStatus int
+	// DomainID places the link on one of the user's verified custom domains.
+	DomainID *int64
+	// WorkspaceID creates the link in a workspace instead of the user's
+	// personal space.
+	WorkspaceID *int64
+	// Alias is a custom short code, unique per domain.
+	Alias string
+	Tags  []string
+	// CreatedAt preserves the original creation time of imported links.
+	CreatedAt *time.Time
+	// QRStyle customizes the link's QR code; nil uses the defaults.
+	QRStyle *QRStyleInput
+	ExpirySettings
+
+	// qrStyle is the resolved QRStyle, set by prepareCreate.
+	qrStyle qr.Style
 }
 
+var (
+	ErrURLNotFound = errors.New("URL not found")
+	ErrURLExpired  = errors.New("URL has expired")
+	ErrURLDisabled = errors.New("URL is paused")
+)
+
 type URLStats struct {
-	ID          int64     `json:"id"`
-	OriginalURL string    `json:"original_url"`
-	ShortURL    string    `json:"short_url"`
-	QRURL       string    `json:"qr_url"`
-	Clicks      int       `json:"clicks"`
-	CreatedAt   time.Time `json:"created_at"`
-	ExpiresAt   time.Time `json:"expires_at"`
+	ID              int64      `json:"id"`
+	OriginalURL     string     `json:"original_url"`
+	ShortURL        string     `json:"short_url"`
+	QRURL           string     `json:"qr_url"`
+	Clicks          int        `json:"clicks"`
+	QRScans         int        `json:"qr_scans"`
+	NFCTaps         int        `json:"nfc_taps"`
+	LinkClicks      int        `json:"link_clicks"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	Enabled         bool       `json:"enabled"`
+	ExpiredBehavior string     `json:"expired_behavior"`
+	FallbackURL     string     `json:"fallback_url,omitempty"`
+	ExpiredMessage  string     `json:"expired_message,omitempty"`
+	RedirectStatus  int        `json:"redirect_status"`
+	WorkspaceID     *int64     `json:"workspace_id"`
+	Tags            Tags       `json:"tags"`
+	QRStyle         qr.Style   `json:"qr_style"`
+	QRStatus        string     `json:"qr_status"`
 }
 
 type service struct {
-	repo         Repository
-	clickService click.Service
-	cld          *cloudinary.Cloudinary
+	repo             Repository
+	clickService     click.Service
+	domainService    domain.Service
+	workspaceService workspace.Service
+	store            storage.Storage
+	codeFilter       CodeFilter
+	qrRenderer       *qr.Renderer
+	qrQueue          QRQueue
 }
 
-func NewService(repo Repository, clickService click.Service, cld *cloudinary.Cloudinary) Service {
-	return &service{repo: repo, clickService: clickService, cld: cld}
+func NewService(repo Repository, clickService click.Service, domainService domain.Service, workspaceService workspace.Service, store storage.Storage, codeFilter CodeFilter, qrRenderer *qr.Renderer, qrQueue QRQueue) Service {
+	return &service{
+		repo:             repo,
+		clickService:     clickService,
+		domainService:    domainService,
+		workspaceService: workspaceService,
+		store:            store,
+		codeFilter:       codeFilter,
+		qrRenderer:       qrRenderer,
+		qrQueue:          qrQueue,
+	}
 }
 
 const base62 = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
@@ -59,106 +141,527 @@ var blacklistedDomains = []string{
 	"local",
 }
 
-func (s *service) CreateShortURL(userID int64, originalURL string, expiresAt time.Time) (string, string, error) {
+func (s *service) CreateShortURL(userID int64, in CreateURLInput) (*URL, error) {
 
-	if err := validateURL(originalURL); err != nil {
-		return "", "", err
+	hostname, err := s.prepareCreate(userID, &in)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountURLsCreatedToday(userID, in.WorkspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rate limit")
 	}
+	if count >= 100 {
+		return nil, errors.New("daily limit exceeded (100 URLs per day)")
+	}
+
+	// An existing link is only handed back for a plain request: it would not
+	// carry the alias or settings asked for.
+	if !in.customized() {
+		existingURL, err := s.repo.FindExistingURL(userID, in.WorkspaceID, in.DomainID, in.OriginalURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing URL")
+		}
+
+		if existingURL != nil {
+
+			if !existingURL.IsExpired(time.Now()) {
+				return existingURL, nil
+			}
+
+		}
+	}
+
+	return s.createNewShortURL(userID, hostname, in)
+}
+
+// customized reports whether a normalized create request asks for anything
+// beyond the defaults.
+func (in *CreateURLInput) customized() bool {
+	return in.Alias != "" ||
+		in.QRStyle != nil ||
+		in.RedirectStatus != http.StatusFound ||
+		in.ExpiresAt != nil ||
+		in.ExpiredBehavior != ExpiredNotFound ||
+		len(in.Tags) > 0
+}
 
-	if expiresAt.Before(time.Now()) {
-		return "", "", errors.New("expiration date must be in the future")
+func (s *service) ImportURL(userID int64, in CreateURLInput) (*URL, error) {
+	hostname, err := s.prepareCreate(userID, &in)
+	if err != nil {
+		return nil, err
 	}
 
-	count, err := s.repo.CountURLsCreatedToday(userID)
+	existingURL, err := s.repo.FindExistingURL(userID, in.WorkspaceID, in.DomainID, in.OriginalURL)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to check rate limit")
+		return nil, fmt.Errorf("failed to check existing URL")
 	}
-	if count >= 100 {
-		return "", "", errors.New("daily limit exceeded (100 URLs per day)")
+	if existingURL != nil && !existingURL.IsExpired(time.Now()) {
+		return existingURL, ErrDuplicateURL
+	}
+
+	return s.createNewShortURL(userID, hostname, in)
+}
+
+// prepareCreate validates and normalizes a create request, checks the
+// caller may create links in the target scope, and returns the custom
+// domain's hostname if one was chosen.
+func (s *service) prepareCreate(userID int64, in *CreateURLInput) (string, error) {
+	if err := validateURL(in.OriginalURL); err != nil {
+		return "", err
 	}
 
-	existingURL, err := s.repo.FindExistingURL(userID, originalURL)
+	if in.ExpiresAt != nil && in.ExpiresAt.Before(time.Now()) {
+		return "", errors.New("expiration date must be in the future")
+	}
+
+	if err := in.ExpirySettings.normalize(); err != nil {
+		return "", err
+	}
+
+	if in.RedirectStatus == 0 {
+		in.RedirectStatus = http.StatusFound
+	}
+	if err := validateRedirectStatus(in.RedirectStatus); err != nil {
+		return "", err
+	}
+
+	tags, err := normalizeTags(in.Tags)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to check existing URL")
+		return "", err
 	}
+	in.Tags = tags
 
-	if existingURL != nil {
+	if in.qrStyle, err = in.QRStyle.apply(qr.DefaultStyle()); err != nil {
+		return "", err
+	}
 
-		if existingURL.ExpiresAt.After(time.Now()) {
-			return existingURL.ShortCode, existingURL.QRURL, nil
+	if err := s.authorizeScope(userID, in.WorkspaceID, workspace.PermEdit); err != nil {
+		return "", err
+	}
+
+	hostname := ""
+	if in.DomainID != nil {
+		d, err := s.domainService.GetVerified(userID, *in.DomainID)
+		if err != nil {
+			return "", err
 		}
+		hostname = d.Hostname
+	}
 
+	if in.Alias != "" {
+		if err := validateAlias(in.Alias); err != nil {
+			return "", err
+		}
+		if err := s.codeFilter.Check(in.Alias); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidAlias, err)
+		}
+		existing, err := s.repo.GetByShortCode(in.DomainID, in.Alias)
+		if err != nil {
+			return "", fmt.Errorf("failed to check alias: %w", err)
+		}
+		if existing != nil {
+			return "", ErrAliasTaken
+		}
 	}
 
-	return s.createNewShortURL(userID, originalURL, expiresAt)
+	return hostname, nil
 }
 
-func (s *service) createNewShortURL(userID int64, originalURL string, expiresAt time.Time) (string, string, error) {
-	baseURL := os.Getenv("FRONTEND_URL")
-	if baseURL == "" {
-		baseURL = "https://shorty-black.vercel.app"
+func (s *service) createNewShortURL(userID int64, hostname string, in CreateURLInput) (*URL, error) {
+	u := &URL{
+		UserID:          userID,
+		OriginalURL:     in.OriginalURL,
+		ExpiresAt:       in.ExpiresAt,
+		ExpiredBehavior: in.ExpiredBehavior,
+		FallbackURL:     in.FallbackURL,
+		ExpiredMessage:  in.ExpiredMessage,
+		RedirectStatus:  in.RedirectStatus,
+		DomainID:        in.DomainID,
+		Domain:          hostname,
+		WorkspaceID:     in.WorkspaceID,
+		Enabled:         true,
+		ShortCode:       in.Alias,
+		Tags:            in.Tags,
+		QRStyle:         in.qrStyle,
+	}
+	if in.CreatedAt != nil {
+		u.CreatedAt = *in.CreatedAt
+	}
+
+	// The ID is reserved first so the row is inserted with its final short
+	// code and never exists with an empty one.
+	id, err := s.repo.NextID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve URL ID: %w", err)
+	}
+	u.ID = id
+
+	if u.ShortCode == "" {
+		if u.ShortCode, err = s.nextShortCode(u.DomainID, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.repo.Create(u); err != nil {
+		// A create racing for the same alias got in after the check in
+		// prepareCreate.
+		if in.Alias != "" && isUniqueViolation(err) {
+			return nil, ErrAliasTaken
+		}
+		return nil, fmt.Errorf("failed to create URL record: %w", err)
 	}
-	baseURL += "/l/"
+	u.QRStatus = QRStatusPending
+
+	// The image is uploaded in the background; until then the on-demand
+	// endpoint serves it.
+	s.qrQueue.Enqueue(id)
 
-	id, err := s.repo.Create(userID, originalURL, "", "", expiresAt)
+	return u, nil
+}
+
+// RegenerateQR applies the style changes to the link's stored style and
+// queues the image for re-upload. The on-demand endpoint serves the new
+// style in the meantime.
+func (s *service) RegenerateQR(userID, id int64, in *QRStyleInput) (*URL, error) {
+	u, err := s.getAuthorized(userID, id, workspace.PermEdit)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to create URL record: %w", err)
+		return nil, err
 	}
 
-	shortCode := encodeBase62(id)
-	shortURL := baseURL + shortCode
+	if u.QRStyle, err = in.apply(u.QRStyle); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateQRStyle(u.ID, u.QRStyle); err != nil {
+		return nil, fmt.Errorf("failed to save QR code: %w", err)
+	}
+	u.QRStatus = QRStatusPending
+	s.qrQueue.Enqueue(u.ID)
+	return u, nil
+}
 
-	qrBytes, err := qrcode.Encode(shortURL, qrcode.Medium, 256)
+func (s *service) RenderQR(userID, id int64, opts qr.Options) (*qr.Output, string, error) {
+	u, err := s.getAuthorized(userID, id, workspace.PermView)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to generate QR code: %w", err)
+		return nil, "", err
 	}
 
-	uploadResp, err := s.cld.Upload.Upload(context.Background(), bytes.NewReader(qrBytes), uploader.UploadParams{
-		PublicID: "qr_" + shortCode,
-		Folder:   "qr_codes",
-	})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	out, err := s.qrRenderer.Render(ctx, u.QRPayloadURL(), u.QRStyle, opts)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to upload QR code: %w", err)
+		return nil, "", err
 	}
+	return out, qrPublicID(u) + "." + out.Extension, nil
+}
 
-	err = s.repo.UpdateShortCodeAndQR(id, shortCode, uploadResp.SecureURL)
+// PublicQR renders the QR code of the link behind a short code on the given
+// host. Paused and expired links still get their code: it is printed
+// material and may be re-enabled.
+func (s *service) PublicQR(host, shortCode string, opts qr.Options) (*qr.Output, error) {
+	u, err := s.resolve(host, shortCode)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to update short code and QR URL: %w", err)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return s.qrRenderer.Render(ctx, u.QRPayloadURL(), u.QRStyle, opts)
+}
+
+// QRPrefix starts the storage key of every link's QR image.
+const QRPrefix = "qr_codes/qr_"
+
+// qrPublicID names a link's stored QR image. Codes are only unique per
+// domain, so links on custom domains carry the hostname in the name.
+func qrPublicID(u *URL) string {
+	if u.Domain != "" {
+		return "qr_" + u.Domain + "_" + u.ShortCode
+	}
+	return "qr_" + u.ShortCode
+}
+
+// QRObjectKey is the storage key of a link's QR image.
+func QRObjectKey(u *URL) string {
+	return "qr_codes/" + qrPublicID(u) + ".png"
+}
+
+// QRObjectCodes returns the short codes a QR image key could belong to.
+// Hostnames may not hold underscores, but codes can, so every split after
+// an underscore is a candidate; callers match QRObjectKey of the links
+// found.
+func QRObjectCodes(key string) []string {
+	name, ok := strings.CutPrefix(key, QRPrefix)
+	if !ok {
+		return nil
+	}
+	name, ok = strings.CutSuffix(name, ".png")
+	if !ok || name == "" {
+		return nil
+	}
+	codes := []string{name}
+	for i := 0; i < len(name); i++ {
+		if name[i] == '_' && i+1 < len(name) {
+			codes = append(codes, name[i+1:])
+		}
+	}
+	return codes
+}
+
+// deleteQRAsset removes a link's stored QR image. Failures are only logged:
+// the reaper's orphan reconciliation retries them later.
+func (s *service) deleteQRAsset(u *URL) {
+	if u.QRURL == "" {
+		return
+	}
+	if err := s.store.Delete(context.Background(), QRObjectKey(u)); err != nil {
+		log.Printf("❌ Failed to delete QR image for link %d: %v", u.ID, err)
+	}
+}
+
+// GetOriginalURL resolves a short code on the requested host and records the
+// click. Hosts that are not verified custom domains resolve against the
+// default domain. When the link exists but cannot be followed, the URL is
+// returned together with ErrURLExpired or ErrURLDisabled so the caller can
+// apply its settings.
+func (s *service) GetOriginalURL(host, shortCode string, event click.ClickEvent) (*URL, error) {
+	u, err := s.resolve(host, shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if !u.Enabled {
+		return u, ErrURLDisabled
+	}
+
+	now := time.Now()
+	if u.IsExpired(now) {
+		// Visits sent on to the fallback are still visits to this link.
+		if u.ExpiredBehavior == ExpiredFallback {
+			event.URLID = u.ID
+			event.Destination = u.FallbackURL
+			go s.clickService.AddClick(event)
+		}
+		return u, ErrURLExpired
 	}
 
-	return shortCode, uploadResp.SecureURL, nil
+	event.URLID = u.ID
+	event.Destination = u.OriginalURL
+	go s.clickService.AddClick(event)
+
+	if isPermanentRedirect(u.RedirectStatus) {
+		s.markPermanentServed(u, now)
+	}
+
+	return u, nil
+}
+
+// markPermanentServed records that u was served with a permanent redirect,
+// at most once per permanentServedResolution.
+func (s *service) markPermanentServed(u *URL, now time.Time) {
+	if u.PermanentServedAt != nil && now.Sub(*u.PermanentServedAt) < permanentServedResolution {
+		return
+	}
+	go func() {
+		if err := s.repo.MarkPermanentServed(u.ID, now); err != nil {
+			log.Printf("❌ Failed to record permanent redirect for link %d: %v", u.ID, err)
+		}
+	}()
 }
 
-func (s *service) GetOriginalURL(shortCode string) (string, error) {
-	u, err := s.repo.GetByShortCode(shortCode)
+// resolve finds the link for a short code on the requested host.
+func (s *service) resolve(host, shortCode string) (*URL, error) {
+	var domainID *int64
+	d, err := s.domainService.ResolveHost(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve host: %w", err)
+	}
+	if d != nil {
+		domainID = &d.ID
+	}
+
+	u, err := s.repo.GetByShortCode(domainID, shortCode)
 	if err != nil || u == nil {
-		return "", errors.New("URL not found")
+		return nil, ErrURLNotFound
 	}
+	return u, nil
+}
 
-	if u.ExpiresAt.Before(time.Now()) {
-		return "", errors.New("URL has expired")
+// ListURLs lists the caller's personal links, or a workspace's links when
+// workspaceID is set.
+func (s *service) ListURLs(userID int64, workspaceID *int64) ([]*URL, error) {
+	if err := s.authorizeScope(userID, workspaceID, workspace.PermView); err != nil {
+		return nil, err
 	}
+	urls, err := s.repo.List(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range urls {
+		u.QRURL = u.QRImageURL()
+	}
+	return urls, nil
+}
 
-	go s.clickService.AddClick(u.ID)
+func (s *service) GetUserStats(userID int64, workspaceID *int64) ([]*URLStats, error) {
+	if err := s.authorizeScope(userID, workspaceID, workspace.PermView); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserStats(userID, workspaceID)
+}
 
-	return u.OriginalURL, nil
+func (s *service) GetURLByID(userID, id int64) (*URL, error) {
+	return s.getAuthorized(userID, id, workspace.PermView)
 }
 
-func (s *service) ListURLs(userID int64) ([]*URL, error) {
-	return s.repo.List(userID)
+func (s *service) DeleteURL(userID, id int64) error {
+	u, err := s.getAuthorized(userID, id, workspace.PermDelete)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteByID(id); err != nil {
+		return err
+	}
+	s.deleteQRAsset(u)
+	return nil
 }
 
-func (s *service) GetUserStats(userID int64) ([]*URLStats, error) {
-	return s.repo.GetUserStats(userID)
+func (s *service) DiscardURL(userID, id int64) error {
+	u, err := s.repo.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to load URL: %w", err)
+	}
+	if u == nil || u.UserID != userID {
+		return ErrURLNotFound
+	}
+	if err := s.repo.DeleteByID(id); err != nil {
+		return err
+	}
+	s.deleteQRAsset(u)
+	return nil
 }
 
-func (s *service) GetURLByID(id int64) (*URL, error) {
-	return s.repo.GetByID(id)
+// SetEnabled pauses or resumes a link. Clicks recorded so far are kept. A
+// link cannot be paused while browsers may still follow a cached permanent
+// redirect past the pause.
+func (s *service) SetEnabled(userID, id int64, enabled bool) error {
+	u, err := s.getAuthorized(userID, id, workspace.PermEdit)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		if err := checkRedirectCache(u, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.SetEnabled(id, enabled)
 }
 
-func (s *service) DeleteURL(id int64) error {
-	return s.repo.DeleteByID(id)
+// RenewExpiry changes when a link expires. Bringing the expiry forward waits
+// until cached permanent redirects would no longer outlive it.
+func (s *service) RenewExpiry(userID, id int64, in RenewExpiryInput) (*URL, error) {
+	u, err := s.getAuthorized(userID, id, workspace.PermEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt, err := in.resolve(u, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cached permanent redirects last until the old expiry at most, so only
+	// bringing it forward can leave browsers following the link past the
+	// new one.
+	shortened := expiresAt != nil && (u.ExpiresAt == nil || expiresAt.Before(*u.ExpiresAt))
+	if shortened && expiresAt.Before(u.redirectCachedUntil()) {
+		if err := checkRedirectCache(u, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.UpdateExpiry(id, expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to update expiry: %w", err)
+	}
+
+	u.ExpiresAt = expiresAt
+	return u, nil
+}
+
+// UpdateRedirectStatus changes the redirect code. Leaving 301/308 waits
+// until cached permanent redirects have expired, or the destination could
+// then change while browsers still follow the old one.
+func (s *service) UpdateRedirectStatus(userID, id int64, status int) error {
+	u, err := s.getAuthorized(userID, id, workspace.PermEdit)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRedirectStatus(status); err != nil {
+		return err
+	}
+	if !isPermanentRedirect(status) {
+		if err := checkRedirectCache(u, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.UpdateRedirectStatus(id, status)
 }
+
+// UpdateDestination changes where a link points. Links served with a
+// permanent redirect are locked, since clients may still hold a cached copy
+// of the old destination, and stay locked until that copy has expired.
+func (s *service) UpdateDestination(userID, id int64, originalURL string) error {
+	u, err := s.getAuthorized(userID, id, workspace.PermEdit)
+	if err != nil {
+		return err
+	}
+
+	if isPermanentRedirect(u.RedirectStatus) {
+		return ErrDestinationLocked
+	}
+	if err := checkRedirectCache(u, time.Now()); err != nil {
+		return err
+	}
+
+	if err := validateURL(originalURL); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateDestination(id, originalURL)
+}
+
+func (s *service) UpdateExpiredBehavior(userID, id int64, settings ExpirySettings) error {
+	if _, err := s.getAuthorized(userID, id, workspace.PermEdit); err != nil {
+		return err
+	}
+
+	if err := settings.normalize(); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateExpiredBehavior(id, settings.ExpiredBehavior, settings.FallbackURL, settings.ExpiredMessage)
+}
+
+func (s *service) UpdateTags(userID, id int64, tags []string) error {
+	if _, err := s.getAuthorized(userID, id, workspace.PermEdit); err != nil {
+		return err
+	}
+
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdateTags(id, normalized)
+}
+
 func encodeBase62(num int64) string {
 	if num == 0 {
 		return string(base62[0])
@@ -313,4 +816,4 @@ func isValidIPv4(host string) bool {
 	}
 
 	return true
-}
\ No newline at end of file
+}
@@ -0,0 +1,64 @@
+package url
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	MaxTags      = 10
+	MaxTagLength = 32
+)
+
+// Tags is a link's tag list. It is exchanged with Postgres as JSON (see
+// selectURL and Create) so the text[] column does not depend on driver
+// array support.
+type Tags []string
+
+func (t *Tags) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*t = Tags{}
+		return nil
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return fmt.Errorf("cannot scan %T into Tags", src)
+	}
+	return json.Unmarshal(raw, (*[]string)(t))
+}
+
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		t = Tags{}
+	}
+	raw, err := json.Marshal([]string(t))
+	return string(raw), err
+}
+
+// normalizeTags lowercases, trims and de-duplicates tags.
+func normalizeTags(tags []string) (Tags, error) {
+	out := Tags{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > MaxTagLength {
+			return nil, fmt.Errorf("tag '%s' too long (max %d characters)", tag, MaxTagLength)
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	if len(out) > MaxTags {
+		return nil, errors.New("too many tags (max 10)")
+	}
+	return out, nil
+}
@@ -4,4 +4,6 @@ type User struct {
 	ID           int
 	Username     string
 	PasswordHash string
+	Email        string
+	IsAdmin      bool
 }
@@ -3,8 +3,9 @@ package user
 import "database/sql"
 
 type Repository interface {
-	Create(username, password string) error
+	Create(username, password, email string) error
 	GetByUsername(username string) (*User, error)
+	GetByID(id int64) (*User, error)
 }
 
 type repository struct {
@@ -15,15 +16,27 @@ func NewRepository(db *sql.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) Create(username, password string) error {
-	_, err := r.db.Exec("INSERT INTO users (username, password) VALUES ($1,$2)", username, password)
+func (r *repository) Create(username, password, email string) error {
+	_, err := r.db.Exec("INSERT INTO users (username, password, email) VALUES ($1,$2,$3)", username, password, email)
 	return err
 }
 
 func (r *repository) GetByUsername(username string) (*User, error) {
-	row := r.db.QueryRow("SELECT id, username, password FROM users WHERE username=$1", username)
+	row := r.db.QueryRow("SELECT id, username, password, email, is_admin FROM users WHERE username=$1", username)
 	u := &User{}
-	if err := row.Scan(&u.ID, &u.Username, &u.PasswordHash); err != nil {
+	if err := row.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Email, &u.IsAdmin); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+func (r *repository) GetByID(id int64) (*User, error) {
+	row := r.db.QueryRow("SELECT id, username, password, email, is_admin FROM users WHERE id=$1", id)
+	u := &User{}
+	if err := row.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Email, &u.IsAdmin); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
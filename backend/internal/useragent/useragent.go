@@ -0,0 +1,183 @@
+// Package useragent reads device, browser and OS details out of User-Agent
+// headers with a rule set embedded in the binary. The rules are plain JSON
+// so they can be updated without code changes, or replaced at run time from
+// a file.
+//
+// iPads on iPadOS 13 and later ask for desktop sites by default, and Safari
+// then sends the same User-Agent as Safari on a Mac, so those visits count
+// as macOS desktops; nothing in the header tells them apart. Apps and other
+// browsers that keep a "Mobile/" token after "Macintosh" are counted as iOS
+// tablets.
+package useragent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Device types.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceTV      = "tv"
+	DeviceConsole = "console"
+	DeviceBot     = "bot"
+)
+
+// Agent is what a User-Agent says about the visitor. Fields the rules
+// cannot tell are empty.
+type Agent struct {
+	Device         string
+	Browser        string
+	BrowserVersion string
+	OS             string
+	OSVersion      string
+}
+
+//go:embed rules.json
+var defaultRules []byte
+
+// ruleFile is the format of rules.json. Each list is tried in order and the
+// first match wins. Family and version may refer to submatches as $1, $2;
+// the version defaults to the first submatch. A device rule with Not only
+// matches when that expression does not.
+type ruleFile struct {
+	Devices []struct {
+		Regex string `json:"regex"`
+		Not   string `json:"not"`
+		Type  string `json:"type"`
+	} `json:"devices"`
+	DefaultDevice string     `json:"default_device"`
+	Browsers      []ruleSpec `json:"browsers"`
+	OS            []ruleSpec `json:"os"`
+}
+
+type ruleSpec struct {
+	Regex   string `json:"regex"`
+	Family  string `json:"family"`
+	Version string `json:"version"`
+}
+
+type deviceRule struct {
+	re  *regexp.Regexp
+	not *regexp.Regexp
+	typ string
+}
+
+type familyRule struct {
+	re      *regexp.Regexp
+	family  string
+	version string
+}
+
+// Parser applies a rule set. It is safe for concurrent use.
+type Parser struct {
+	devices       []deviceRule
+	defaultDevice string
+	browsers      []familyRule
+	os            []familyRule
+}
+
+// Default returns a parser for the embedded rules.
+func Default() *Parser {
+	p, err := New(defaultRules)
+	if err != nil {
+		panic("useragent: invalid embedded rules: " + err.Error())
+	}
+	return p
+}
+
+// Load reads a rule file in the format of the embedded rules.json.
+func Load(path string) (*Parser, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(data)
+}
+
+// New compiles a JSON rule set.
+func New(data []byte) (*Parser, error) {
+	var f ruleFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+
+	p := &Parser{defaultDevice: f.DefaultDevice}
+	for _, d := range f.Devices {
+		re, err := regexp.Compile(d.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid device rule %q: %w", d.Regex, err)
+		}
+		rule := deviceRule{re: re, typ: d.Type}
+		if d.Not != "" {
+			if rule.not, err = regexp.Compile(d.Not); err != nil {
+				return nil, fmt.Errorf("invalid device rule %q: %w", d.Not, err)
+			}
+		}
+		p.devices = append(p.devices, rule)
+	}
+	var err error
+	if p.browsers, err = compile(f.Browsers); err != nil {
+		return nil, err
+	}
+	if p.os, err = compile(f.OS); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func compile(specs []ruleSpec) ([]familyRule, error) {
+	rules := make([]familyRule, 0, len(specs))
+	for _, s := range specs {
+		re, err := regexp.Compile(s.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %w", s.Regex, err)
+		}
+		if s.Family == "" {
+			return nil, fmt.Errorf("rule %q has no family", s.Regex)
+		}
+		if s.Version == "" {
+			s.Version = "$1"
+		}
+		rules = append(rules, familyRule{re: re, family: s.Family, version: s.Version})
+	}
+	return rules, nil
+}
+
+// Parse reads ua. An empty User-Agent yields an empty Agent.
+func (p *Parser) Parse(ua string) Agent {
+	var a Agent
+	if strings.TrimSpace(ua) == "" {
+		return a
+	}
+
+	a.Device = p.defaultDevice
+	for _, d := range p.devices {
+		if d.re.MatchString(ua) && (d.not == nil || !d.not.MatchString(ua)) {
+			a.Device = d.typ
+			break
+		}
+	}
+	a.Browser, a.BrowserVersion = match(p.browsers, ua)
+	a.OS, a.OSVersion = match(p.os, ua)
+	return a
+}
+
+func match(rules []familyRule, ua string) (family, version string) {
+	for _, r := range rules {
+		m := r.re.FindStringSubmatchIndex(ua)
+		if m == nil {
+			continue
+		}
+		family = string(r.re.ExpandString(nil, r.family, ua, m))
+		version = string(r.re.ExpandString(nil, r.version, ua, m))
+		return family, strings.Trim(version, ".")
+	}
+	return "", ""
+}
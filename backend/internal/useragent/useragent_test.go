@@ -0,0 +1,183 @@
+package useragent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDefaultRules(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want Agent
+	}{
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			Agent{DeviceDesktop, "Chrome", "120.0.0.0", "Windows", "10"},
+		},
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			Agent{DeviceDesktop, "Edge", "120.0.2210.91", "Windows", "10"},
+		},
+		{
+			"Mozilla/5.0 (Windows NT 6.1; WOW64; Trident/7.0; rv:11.0) like Gecko",
+			Agent{DeviceDesktop, "Internet Explorer", "11.0", "Windows", "7"},
+		},
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			Agent{DeviceDesktop, "Safari", "17.2", "macOS", "10.15"},
+		},
+		{
+			"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			Agent{DeviceDesktop, "Firefox", "121.0", "Ubuntu", ""},
+		},
+		{
+			"Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			Agent{DeviceDesktop, "Chrome", "120.0.0.0", "Chrome OS", ""},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			Agent{DeviceMobile, "Safari", "17.2", "iOS", "17.2"},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			Agent{DeviceMobile, "Chrome", "120.0.6099.119", "iOS", "17.2"},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Instagram 312.0.0.22.114",
+			Agent{DeviceMobile, "Instagram", "312.0.0.22.114", "iOS", "17.1"},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148",
+			Agent{DeviceMobile, "iOS WebView", "", "iOS", "16.6"},
+		},
+		{
+			"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+			Agent{DeviceTablet, "Safari", "16.6", "iOS", "16.6"},
+		},
+		{
+			// iPadOS desktop mode: Safari is indistinguishable from a Mac.
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+			Agent{DeviceDesktop, "Safari", "17.1", "macOS", "10.15"},
+		},
+		{
+			// iPadOS desktop mode in Chrome and in an app's web view.
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			Agent{DeviceTablet, "Chrome", "120.0.6099.119", "iOS", ""},
+		},
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148",
+			Agent{DeviceTablet, "", "", "iOS", ""},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			Agent{DeviceMobile, "Chrome", "120.0.6099.144", "Android", "14"},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			Agent{DeviceTablet, "Chrome", "120.0.0.0", "Android", "13"},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 13; SAMSUNG SM-S911B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			Agent{DeviceMobile, "Samsung Internet", "23.0", "Android", "13"},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 10; K; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/120.0.6099.144 Mobile Safari/537.36",
+			Agent{DeviceMobile, "Android WebView", "120.0.6099.144", "Android", "10"},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 12; SM-G991B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36 OPR/79.0.4195.76498",
+			Agent{DeviceMobile, "Opera", "79.0.4195.76498", "Android", "12"},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 12; Pixel 6 Build/SD1A.210817.023; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/94.0.4606.71 Mobile Safari/537.36 [FB_IAB/FB4A;FBAV/340.0.0.27.113;]",
+			Agent{DeviceMobile, "Facebook", "340.0.0.27.113", "Android", "12"},
+		},
+		{
+			"Mozilla/5.0 (SMART-TV; Linux; Tizen 6.0) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/4.0 Chrome/76.0.3809.146 TV Safari/537.36",
+			Agent{DeviceTV, "Samsung Internet", "4.0", "Tizen", "6.0"},
+		},
+		{
+			"Mozilla/5.0 (PlayStation 5 3.11) AppleWebKit/605.1.15 (KHTML, like Gecko)",
+			Agent{DeviceConsole, "", "", "PlayStation", "5"},
+		},
+		{
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			Agent{DeviceBot, "Googlebot", "2.1", "", ""},
+		},
+		{
+			"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm) Chrome/116.0.1938.76 Safari/537.36",
+			Agent{DeviceBot, "bingbot", "2.0", "", ""},
+		},
+		{
+			"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)",
+			Agent{DeviceBot, "facebookexternalhit", "1.1", "", ""},
+		},
+		{
+			"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)",
+			Agent{DeviceBot, "Slackbot", "", "", ""},
+		},
+		{"curl/8.4.0", Agent{DeviceBot, "curl", "8.4.0", "", ""}},
+		{"Wget/1.21.4", Agent{DeviceBot, "Wget", "1.21.4", "", ""}},
+		{"something unknown", Agent{Device: DeviceDesktop}},
+		{"", Agent{}},
+		{"   ", Agent{}},
+	}
+
+	p := Default()
+	for _, tt := range tests {
+		if got := p.Parse(tt.ua); got != tt.want {
+			t.Errorf("Parse(%q)\n got %+v\nwant %+v", tt.ua, got, tt.want)
+		}
+	}
+}
+
+func TestNewRejects(t *testing.T) {
+	tests := map[string]string{
+		"not JSON":          `{`,
+		"bad device regex":  `{"devices": [{"regex": "(", "type": "x"}]}`,
+		"bad not regex":     `{"devices": [{"regex": "x", "not": "(", "type": "x"}]}`,
+		"bad browser regex": `{"browsers": [{"regex": "(", "family": "x"}]}`,
+		"browser no family": `{"browsers": [{"regex": "x"}]}`,
+		"bad os regex":      `{"os": [{"regex": "[", "family": "x"}]}`,
+	}
+	for name, rules := range tests {
+		if _, err := New([]byte(rules)); err == nil {
+			t.Errorf("%s: New accepted %s", name, rules)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	rules := `{
+		"devices": [{"regex": "Phone", "not": "Tab", "type": "mobile"}],
+		"default_device": "other",
+		"browsers": [{"regex": "(\\w+)Browser/(\\d+)\\.(\\d+)", "family": "$1", "version": "$2.$3."}],
+		"os": [{"regex": "ExOS", "family": "ExOS"}]
+	}`
+	if err := os.WriteFile(path, []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ua   string
+		want Agent
+	}{
+		{"Phone ExOS FooBrowser/1.2", Agent{"mobile", "Foo", "1.2", "ExOS", ""}},
+		{"Phone Tab", Agent{Device: "other"}},
+	}
+	for _, tt := range tests {
+		if got := p.Parse(tt.ua); got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.ua, got, tt.want)
+		}
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Load of a missing file succeeded")
+	}
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// ErrAddressNotAllowed is returned when a host named in user input resolves
+// to an address the server must not reach.
+var ErrAddressNotAllowed = errors.New("address is not allowed")
+
+// PublicDialer returns a dialer for requests to hosts named in user input.
+// The check runs on the resolved address, so names pointing at loopback,
+// private, link-local or unspecified addresses are refused too.
+func PublicDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+				return fmt.Errorf("%w: %s", ErrAddressNotAllowed, host)
+			}
+			return nil
+		},
+	}
+}
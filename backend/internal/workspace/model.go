@@ -0,0 +1,69 @@
+package workspace
+
+import "time"
+
+type Workspace struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	OwnerID   int64     `json:"owner_id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Role is the requesting user's role, filled in when listing.
+	Role string `json:"role,omitempty"`
+}
+
+type Member struct {
+	WorkspaceID int64     `json:"workspace_id"`
+	UserID      int64     `json:"user_id"`
+	Username    string    `json:"username"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Permission is an action on a workspace or its links.
+type Permission int
+
+const (
+	// PermView lists links and reads stats.
+	PermView Permission = iota
+	// PermEdit creates links and changes their settings.
+	PermEdit
+	// PermDelete removes links.
+	PermDelete
+	// PermManage adds, removes and re-roles members.
+	PermManage
+	// PermOwn deletes the workspace.
+	PermOwn
+)
+
+var roleRank = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+var minRank = map[Permission]int{
+	PermView:   1,
+	PermEdit:   2,
+	PermDelete: 3,
+	PermManage: 3,
+	PermOwn:    4,
+}
+
+func ValidRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+// Allows reports whether role grants perm. Unknown roles grant nothing.
+func Allows(role string, perm Permission) bool {
+	rank, ok := roleRank[role]
+	return ok && rank >= minRank[perm]
+}
@@ -0,0 +1,171 @@
+package workspace
+
+import "database/sql"
+
+type Repository interface {
+	Create(name string, ownerID int64) (int64, error)
+	GetByID(id int64) (*Workspace, error)
+	ListByUser(userID int64) ([]*Workspace, error)
+	Delete(id int64) error
+	CountLinks(id int64) (int, error)
+	CountPayloads(id int64) (int, error)
+	GetRole(workspaceID, userID int64) (string, error)
+	ListMembers(workspaceID int64) ([]*Member, error)
+	AddMember(workspaceID, userID int64, role string) error
+	UpdateRole(workspaceID, userID int64, role string) error
+	RemoveMember(workspaceID, userID int64) error
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &repository{db: db}
+}
+
+// Create inserts the workspace and its owner membership together.
+func (r *repository) Create(name string, ownerID int64) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(
+		"INSERT INTO workspaces (name, owner_id) VALUES ($1,$2) RETURNING id",
+		name, ownerID,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1,$2,$3)",
+		id, ownerID, RoleOwner,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *repository) GetByID(id int64) (*Workspace, error) {
+	w := &Workspace{}
+	err := r.db.QueryRow(
+		"SELECT id, name, owner_id, created_at FROM workspaces WHERE id=$1", id,
+	).Scan(&w.ID, &w.Name, &w.OwnerID, &w.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+func (r *repository) ListByUser(userID int64) ([]*Workspace, error) {
+	rows, err := r.db.Query(`
+		SELECT w.id, w.name, w.owner_id, w.created_at, m.role
+		FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id
+		WHERE m.user_id = $1
+		ORDER BY w.created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Workspace
+	for rows.Next() {
+		w := &Workspace{}
+		if err := rows.Scan(&w.ID, &w.Name, &w.OwnerID, &w.CreatedAt, &w.Role); err != nil {
+			return nil, err
+		}
+		list = append(list, w)
+	}
+	return list, rows.Err()
+}
+
+func (r *repository) Delete(id int64) error {
+	_, err := r.db.Exec("DELETE FROM workspaces WHERE id=$1", id)
+	return err
+}
+
+func (r *repository) CountLinks(id int64) (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM urls WHERE workspace_id=$1", id).Scan(&count)
+	return count, err
+}
+
+func (r *repository) CountPayloads(id int64) (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM qr_payloads WHERE workspace_id=$1", id).Scan(&count)
+	return count, err
+}
+
+// GetRole returns the user's role, or "" if they are not a member.
+func (r *repository) GetRole(workspaceID, userID int64) (string, error) {
+	var role string
+	err := r.db.QueryRow(
+		"SELECT role FROM workspace_members WHERE workspace_id=$1 AND user_id=$2",
+		workspaceID, userID,
+	).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+func (r *repository) ListMembers(workspaceID int64) ([]*Member, error) {
+	rows, err := r.db.Query(`
+		SELECT m.workspace_id, m.user_id, u.username, m.role, m.created_at
+		FROM workspace_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.workspace_id = $1
+		ORDER BY m.created_at`,
+		workspaceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*Member
+	for rows.Next() {
+		m := &Member{}
+		if err := rows.Scan(&m.WorkspaceID, &m.UserID, &m.Username, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (r *repository) AddMember(workspaceID, userID int64, role string) error {
+	_, err := r.db.Exec(
+		"INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1,$2,$3)",
+		workspaceID, userID, role,
+	)
+	return err
+}
+
+func (r *repository) UpdateRole(workspaceID, userID int64, role string) error {
+	_, err := r.db.Exec(
+		"UPDATE workspace_members SET role=$1 WHERE workspace_id=$2 AND user_id=$3",
+		role, workspaceID, userID,
+	)
+	return err
+}
+
+func (r *repository) RemoveMember(workspaceID, userID int64) error {
+	_, err := r.db.Exec(
+		"DELETE FROM workspace_members WHERE workspace_id=$1 AND user_id=$2",
+		workspaceID, userID,
+	)
+	return err
+}
@@ -0,0 +1,228 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"url-shortener/internal/user"
+)
+
+var (
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	ErrForbidden         = errors.New("insufficient workspace role")
+	ErrMemberNotFound    = errors.New("member not found")
+	ErrAlreadyMember     = errors.New("user is already a member")
+	ErrInvalidRole       = errors.New("role must be one of owner, admin, editor, viewer")
+)
+
+type Service interface {
+	Create(userID int64, name string) (*Workspace, error)
+	List(userID int64) ([]*Workspace, error)
+	Get(id int64) (*Workspace, error)
+	Delete(userID, id int64) error
+	Members(userID, id int64) ([]*Member, error)
+	AddMember(userID, id int64, username, role string) error
+	UpdateMemberRole(userID, id, memberID int64, role string) error
+	RemoveMember(userID, id, memberID int64) error
+	// Authorize checks that userID holds a role granting perm. Non-members
+	// get ErrWorkspaceNotFound so workspace IDs are not leaked.
+	Authorize(userID, id int64, perm Permission) (string, error)
+}
+
+type service struct {
+	repo     Repository
+	userRepo user.Repository
+}
+
+func NewService(repo Repository, userRepo user.Repository) Service {
+	return &service{repo: repo, userRepo: userRepo}
+}
+
+func (s *service) Create(userID int64, name string) (*Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, errors.New("name must be between 1 and 100 characters")
+	}
+
+	id, err := s.repo.Create(name, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	w, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	w.Role = RoleOwner
+	return w, nil
+}
+
+func (s *service) List(userID int64) ([]*Workspace, error) {
+	return s.repo.ListByUser(userID)
+}
+
+func (s *service) Get(id int64) (*Workspace, error) {
+	w, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, ErrWorkspaceNotFound
+	}
+	return w, nil
+}
+
+func (s *service) Delete(userID, id int64) error {
+	if _, err := s.Authorize(userID, id, PermOwn); err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountLinks(id)
+	if err != nil {
+		return fmt.Errorf("failed to count links: %w", err)
+	}
+	if count > 0 {
+		return errors.New("workspace still has links; delete or transfer them first")
+	}
+
+	count, err = s.repo.CountPayloads(id)
+	if err != nil {
+		return fmt.Errorf("failed to count QR payloads: %w", err)
+	}
+	if count > 0 {
+		return errors.New("workspace still has QR payloads; delete them first")
+	}
+
+	// Transfers into the workspace go with it, and finished transfers out
+	// of it keep their items without the workspace (see db/schema.sql).
+	return s.repo.Delete(id)
+}
+
+func (s *service) Members(userID, id int64) ([]*Member, error) {
+	if _, err := s.Authorize(userID, id, PermView); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(id)
+}
+
+func (s *service) AddMember(userID, id int64, username, role string) error {
+	callerRole, err := s.Authorize(userID, id, PermManage)
+	if err != nil {
+		return err
+	}
+	if err := checkAssignable(callerRole, role); err != nil {
+		return err
+	}
+
+	u, err := s.userRepo.GetByUsername(username)
+	if err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+	if u == nil {
+		return errors.New("user not found")
+	}
+
+	existing, err := s.repo.GetRole(id, int64(u.ID))
+	if err != nil {
+		return err
+	}
+	if existing != "" {
+		return ErrAlreadyMember
+	}
+
+	return s.repo.AddMember(id, int64(u.ID), role)
+}
+
+func (s *service) UpdateMemberRole(userID, id, memberID int64, role string) error {
+	callerRole, err := s.Authorize(userID, id, PermManage)
+	if err != nil {
+		return err
+	}
+	if err := checkAssignable(callerRole, role); err != nil {
+		return err
+	}
+
+	current, err := s.repo.GetRole(id, memberID)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		return ErrMemberNotFound
+	}
+	if err := checkManageable(callerRole, current); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateRole(id, memberID, role)
+}
+
+func (s *service) RemoveMember(userID, id, memberID int64) error {
+	// Anyone but the owner may leave on their own. Others are authorized
+	// before the member is looked up, so outsiders cannot probe who
+	// belongs to a workspace.
+	if userID == memberID {
+		current, err := s.Authorize(userID, id, PermView)
+		if err != nil {
+			return err
+		}
+		if current == RoleOwner {
+			return errors.New("the owner cannot leave the workspace")
+		}
+		return s.repo.RemoveMember(id, memberID)
+	}
+
+	callerRole, err := s.Authorize(userID, id, PermManage)
+	if err != nil {
+		return err
+	}
+	current, err := s.repo.GetRole(id, memberID)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		return ErrMemberNotFound
+	}
+	if err := checkManageable(callerRole, current); err != nil {
+		return err
+	}
+
+	return s.repo.RemoveMember(id, memberID)
+}
+
+func (s *service) Authorize(userID, id int64, perm Permission) (string, error) {
+	role, err := s.repo.GetRole(id, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load role: %w", err)
+	}
+	if role == "" {
+		return "", ErrWorkspaceNotFound
+	}
+	if !Allows(role, perm) {
+		return role, ErrForbidden
+	}
+	return role, nil
+}
+
+// checkAssignable stops admins from handing out roles above their own.
+// There is exactly one owner, so the owner role is never assignable.
+func checkAssignable(callerRole, role string) error {
+	if !ValidRole(role) {
+		return ErrInvalidRole
+	}
+	if role == RoleOwner || roleRank[role] > roleRank[callerRole] {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// checkManageable stops members from changing someone at or above their own
+// rank, except the owner who may manage everyone else.
+func checkManageable(callerRole, targetRole string) error {
+	if targetRole == RoleOwner {
+		return ErrForbidden
+	}
+	if callerRole != RoleOwner && roleRank[targetRole] >= roleRank[callerRole] {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -8,7 +8,8 @@ const RedirectLToBackend = () => {
   const path = window.location.pathname;
 
   const backendPath = path.replace(/^\/l\//, '');
-  window.location.href = `https://backend-wandering-dust-8240.fly.dev/${backendPath}`;
+  // Keep the query string: QR codes mark scans with ?s=qr.
+  window.location.href = `https://backend-wandering-dust-8240.fly.dev/${backendPath}${window.location.search}`;
   return null;
 };
 